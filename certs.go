@@ -0,0 +1,260 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the AppsCode Community License 1.0.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://github.com/appscode/licenses/raw/1.0.0/AppsCode-Community-1.0.0.md
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	admissionregistrationv1 "k8s.io/client-go/kubernetes/typed/admissionregistration/v1"
+	"k8s.io/client-go/util/cert"
+	"k8s.io/client-go/util/keyutil"
+	"k8s.io/klog/v2"
+)
+
+const (
+	webhookCAFile   = "ca.crt"
+	webhookCertFile = corev1.TLSCertKey
+	webhookKeyFile  = corev1.TLSPrivateKeyKey
+
+	// webhookCertCheckInterval is the interval at which the self-signed certificate is checked for rotation
+	webhookCertCheckInterval = time.Hour
+)
+
+// webhookCertDir is the directory the webhook server reads its serving certificate from
+var webhookCertDir = filepath.Join(os.TempDir(), "k8s-webhook-server", "serving-certs")
+
+// getCABundle returns the CA bundle of the serving certificate of the webhook server
+func getCABundle() ([]byte, error) {
+	return ioutil.ReadFile(filepath.Join(webhookCertDir, webhookCAFile))
+}
+
+// ensureWebhookCerts reads the self-signed serving certificate of the webhook server from the secret shared by
+// the replicas, generates a new one if it is missing or expires soon, and writes it to the certificate
+// directory. It returns true if the CA bundle changed.
+func ensureWebhookCerts(ctx context.Context, kc kubernetes.Interface) (bool, error) {
+	secret, err := kc.CoreV1().Secrets(webhookNamespace).Get(ctx, webhookCertSecret, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		secret = nil
+	} else if err != nil {
+		return false, err
+	}
+
+	if secret == nil || webhookCertsNeedRotation(secret.Data) {
+		var oldData map[string][]byte
+		if secret != nil {
+			oldData = secret.Data
+		}
+		data, err := generateWebhookCerts(oldData)
+		if err != nil {
+			return false, err
+		}
+
+		if secret == nil {
+			secret, err = kc.CoreV1().Secrets(webhookNamespace).Create(ctx, &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      webhookCertSecret,
+					Namespace: webhookNamespace,
+					Labels: map[string]string{
+						"app.kubernetes.io/instance": "dynatrace.kubeform.com",
+						"app.kubernetes.io/part-of":  "kubeform.com",
+					},
+				},
+				Type: corev1.SecretTypeTLS,
+				Data: data,
+			}, metav1.CreateOptions{})
+		} else {
+			secret.Data = data
+			secret, err = kc.CoreV1().Secrets(webhookNamespace).Update(ctx, secret, metav1.UpdateOptions{})
+		}
+		if errors.IsAlreadyExists(err) || errors.IsConflict(err) {
+			// another replica generated the certificate at the same time, use its certificate
+			return ensureWebhookCerts(ctx, kc)
+		}
+		if err != nil {
+			return false, err
+		}
+		klog.Infof("generated the serving certificate of the webhook server in secret %s/%s", webhookNamespace, webhookCertSecret)
+	}
+
+	return writeWebhookCerts(secret.Data)
+}
+
+// rotateWebhookCerts checks the self-signed serving certificate periodically, and injects the CA bundle into
+// the ValidatingWebhookConfigurations when it changes
+func rotateWebhookCerts(ctx context.Context, kc kubernetes.Interface, vwcClient *admissionregistrationv1.AdmissionregistrationV1Client) {
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		changed, err := ensureWebhookCerts(ctx, kc)
+		if err != nil {
+			klog.Error(err)
+			return
+		}
+		if changed && enableValidatingWebhook {
+			if err := refreshVWCs(vwcClient); err != nil {
+				klog.Error(err)
+			}
+		}
+	}, webhookCertCheckInterval)
+}
+
+// webhookDNSNames returns the names of the service of the webhook server
+func webhookDNSNames() []string {
+	return []string{
+		fmt.Sprintf("%s.%s.svc", webhookName, webhookNamespace),
+		fmt.Sprintf("%s.%s.svc.cluster.local", webhookName, webhookNamespace),
+		fmt.Sprintf("%s.%s", webhookName, webhookNamespace),
+		webhookName,
+	}
+}
+
+// webhookCertsNeedRotation returns true if the certificate of the secret is invalid, doesn't match the service
+// of the webhook server or has less than a third of its validity left
+func webhookCertsNeedRotation(data map[string][]byte) bool {
+	if len(data[webhookCAFile]) == 0 {
+		return true
+	}
+	if _, err := tls.X509KeyPair(data[webhookCertFile], data[webhookKeyFile]); err != nil {
+		return true
+	}
+	certs, err := cert.ParseCertsPEM(data[webhookCertFile])
+	if err != nil {
+		return true
+	}
+	if err := certs[0].VerifyHostname(webhookDNSNames()[0]); err != nil {
+		return true
+	}
+	return time.Now().Add(webhookCertValidity / 3).After(certs[0].NotAfter)
+}
+
+// generateWebhookCerts generates a self-signed CA and a serving certificate signed by it. The CAs of the previous
+// certificate stay in the bundle until they expire, so that the api server keeps trusting the replicas which
+// still serve the previous certificate.
+func generateWebhookCerts(oldData map[string][]byte) (map[string][]byte, error) {
+	now := time.Now()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	caSerial, err := rand.Int(rand.Reader, big.NewInt(math.MaxInt64))
+	if err != nil {
+		return nil, err
+	}
+	caTmpl := &x509.Certificate{
+		SerialNumber:          caSerial,
+		Subject:               pkix.Name{CommonName: "provider-dynatrace-controller-webhook-ca"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(webhookCertValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, caKey.Public(), caKey)
+	if err != nil {
+		return nil, err
+	}
+	caCert, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	serial, err := rand.Int(rand.Reader, big.NewInt(math.MaxInt64))
+	if err != nil {
+		return nil, err
+	}
+	dnsNames := webhookDNSNames()
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: dnsNames[0]},
+		DNSNames:     dnsNames,
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(webhookCertValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, caCert, key.Public(), caKey)
+	if err != nil {
+		return nil, err
+	}
+	keyPEM, err := keyutil.MarshalPrivateKeyToPEM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	caBundle := pem.EncodeToMemory(&pem.Block{Type: cert.CertificateBlockType, Bytes: caDER})
+	if oldCAs, err := cert.ParseCertsPEM(oldData[webhookCAFile]); err == nil {
+		for _, oldCA := range oldCAs {
+			if now.Before(oldCA.NotAfter) {
+				caBundle = append(caBundle, pem.EncodeToMemory(&pem.Block{Type: cert.CertificateBlockType, Bytes: oldCA.Raw})...)
+			}
+		}
+	}
+
+	return map[string][]byte{
+		webhookCAFile:   caBundle,
+		webhookCertFile: pem.EncodeToMemory(&pem.Block{Type: cert.CertificateBlockType, Bytes: der}),
+		webhookKeyFile:  keyPEM,
+	}, nil
+}
+
+// writeWebhookCerts writes the certificate to the certificate directory, which the webhook server reloads it
+// from. It returns true if the CA bundle changed.
+func writeWebhookCerts(data map[string][]byte) (bool, error) {
+	if err := os.MkdirAll(webhookCertDir, 0o755); err != nil {
+		return false, err
+	}
+
+	caChanged := false
+	// the key is written before the certificate, so that the last reload of the webhook server finds a matching pair
+	for _, name := range []string{webhookKeyFile, webhookCertFile, webhookCAFile} {
+		path := filepath.Join(webhookCertDir, name)
+		current, err := ioutil.ReadFile(path)
+		if err == nil && bytes.Equal(current, data[name]) {
+			continue
+		}
+		if err := ioutil.WriteFile(path, data[name], 0o600); err != nil {
+			return false, err
+		}
+		if name == webhookCAFile {
+			caChanged = true
+		}
+	}
+	return caChanged, nil
+}
@@ -0,0 +1,166 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the AppsCode Community License 1.0.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://github.com/appscode/licenses/raw/1.0.0/AppsCode-Community-1.0.0.md
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/util/cert"
+)
+
+func setWebhookFlags(t *testing.T, validity time.Duration) {
+	name, namespace, oldValidity := webhookName, webhookNamespace, webhookCertValidity
+	webhookName, webhookNamespace, webhookCertValidity = "webhook-service", "kubeform", validity
+	t.Cleanup(func() {
+		webhookName, webhookNamespace, webhookCertValidity = name, namespace, oldValidity
+	})
+}
+
+// verifyWebhookCert verifies the serving certificate against the CA bundle
+func verifyWebhookCert(data map[string][]byte, caBundle []byte) error {
+	certs, err := cert.ParseCertsPEM(data[webhookCertFile])
+	if err != nil {
+		return err
+	}
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(caBundle)
+	_, err = certs[0].Verify(x509.VerifyOptions{
+		DNSName:   "webhook-service.kubeform.svc",
+		Roots:     roots,
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	return err
+}
+
+// expiredCA returns a CA which expired an hour ago
+func expiredCA(t *testing.T) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "expired-ca"},
+		NotBefore:             time.Now().Add(-2 * time.Hour),
+		NotAfter:              time.Now().Add(-time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: cert.CertificateBlockType, Bytes: der})
+}
+
+func TestGenerateWebhookCerts(t *testing.T) {
+	setWebhookFlags(t, 3*time.Hour)
+
+	data, err := generateWebhookCerts(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cas, err := cert.ParseCertsPEM(data[webhookCAFile]); err != nil || len(cas) != 1 {
+		t.Fatalf("CA bundle of a new certificate holds %d CAs, want 1: %v", len(cas), err)
+	}
+	if err := verifyWebhookCert(data, data[webhookCAFile]); err != nil {
+		t.Errorf("certificate doesn't verify against its CA bundle: %v", err)
+	}
+	if webhookCertsNeedRotation(data) {
+		t.Error("new certificate needs rotation")
+	}
+
+	// the CA of the previous certificate stays in the bundle, an expired CA is dropped
+	oldData := map[string][]byte{
+		webhookCAFile:   append(append([]byte{}, data[webhookCAFile]...), expiredCA(t)...),
+		webhookCertFile: data[webhookCertFile],
+		webhookKeyFile:  data[webhookKeyFile],
+	}
+	rotated, err := generateWebhookCerts(oldData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cas, err := cert.ParseCertsPEM(rotated[webhookCAFile]); err != nil || len(cas) != 2 {
+		t.Fatalf("CA bundle of a rotated certificate holds %d CAs, want 2: %v", len(cas), err)
+	}
+	if err := verifyWebhookCert(rotated, rotated[webhookCAFile]); err != nil {
+		t.Errorf("rotated certificate doesn't verify against its CA bundle: %v", err)
+	}
+	if err := verifyWebhookCert(data, rotated[webhookCAFile]); err != nil {
+		t.Errorf("previous certificate doesn't verify against the rotated CA bundle: %v", err)
+	}
+	if err := verifyWebhookCert(rotated, data[webhookCAFile]); err == nil {
+		t.Error("rotated certificate verifies against the previous CA bundle, want a new CA")
+	}
+}
+
+func TestWebhookCertsNeedRotation(t *testing.T) {
+	setWebhookFlags(t, 3*time.Hour)
+	valid, err := generateWebhookCerts(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := generateWebhookCerts(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the certificate expires in 30 minutes, less than a third of the validity of 3 hours
+	webhookCertValidity = 30 * time.Minute
+	expiring, err := generateWebhookCerts(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	webhookCertValidity = 3 * time.Hour
+
+	webhookName = "other-service"
+	otherService, err := generateWebhookCerts(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	webhookName = "webhook-service"
+
+	tests := []struct {
+		name string
+		data map[string][]byte
+		want bool
+	}{
+		{name: "valid", data: valid},
+		{name: "no secret data", want: true},
+		{name: "no CA bundle", data: map[string][]byte{webhookCertFile: valid[webhookCertFile], webhookKeyFile: valid[webhookKeyFile]}, want: true},
+		{name: "key of another certificate", data: map[string][]byte{webhookCAFile: valid[webhookCAFile], webhookCertFile: valid[webhookCertFile], webhookKeyFile: other[webhookKeyFile]}, want: true},
+		{name: "invalid certificate", data: map[string][]byte{webhookCAFile: valid[webhookCAFile], webhookCertFile: []byte("invalid"), webhookKeyFile: valid[webhookKeyFile]}, want: true},
+		{name: "other service", data: otherService, want: true},
+		{name: "near expiry", data: expiring, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := webhookCertsNeedRotation(tt.data); got != tt.want {
+				t.Errorf("webhookCertsNeedRotation() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -20,34 +20,42 @@ package alerting
 
 import (
 	"context"
+	"time"
 
 	"github.com/go-logr/logr"
 	tfschema "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	auditlib "go.bytebuilders.dev/audit/lib"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 	meta_util "kmodules.xyz/client-go/meta"
 	alertingv1alpha1 "kubeform.dev/provider-dynatrace-api/apis/alerting/v1alpha1"
 	"kubeform.dev/provider-dynatrace-controller/controllers"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 // ProfileReconciler reconciles a Profile object
 type ProfileReconciler struct {
 	client.Client
-	Log    logr.Logger
-	Scheme *runtime.Scheme
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
 
 	Gvk      schema.GroupVersionKind // GVK of the Resource
 	Provider *tfschema.Provider      // returns a *schema.Provider from the provider package
 	Resource *tfschema.Resource      // returns *schema.Resource
 	TypeName string                  // resource type
+
+	ResyncPeriod time.Duration // interval after which the object is refreshed from Dynatrace
 }
 
 // +kubebuilder:rbac:groups=alerting.dynatrace.kubeform.com,resources=profiles,verbs=get;list;watch;create;update;patch;delete
@@ -71,8 +79,7 @@ func (r *ProfileReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 	gv := r.Gvk.GroupVersion()
 	tName := r.TypeName
 	jsonit := controllers.GetJSONItr(alertingv1alpha1.GetEncoder(), alertingv1alpha1.GetDecoder())
-	err := controllers.StartProcess(rClient, provider, ctx, res, gv, &unstructuredObj, tName, jsonit)
-	return ctrl.Result{}, err
+	return controllers.StartProcess(rClient, r.Recorder, provider, ctx, res, gv, &unstructuredObj, tName, jsonit, r.ResyncPeriod)
 }
 
 func (r *ProfileReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager, auditor *auditlib.EventPublisher, restrictToNamespace string) error {
@@ -83,22 +90,30 @@ func (r *ProfileReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manag
 		}
 	}
 
+	if err := controllers.SetupSecretIndexes(ctx, mgr, &alertingv1alpha1.Profile{}); err != nil {
+		klog.Error(err, "unable to set up secret indexes", alertingv1alpha1.Profile{}.APIVersion, alertingv1alpha1.Profile{}.Kind)
+		return err
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&alertingv1alpha1.Profile{}).
-		WithEventFilter(predicate.Funcs{
-			CreateFunc: func(e event.CreateEvent) bool {
-				return !meta_util.MustAlreadyReconciled(e.Object)
-			},
-			UpdateFunc: func(e event.UpdateEvent) bool {
-				return (e.ObjectNew.(metav1.Object)).GetDeletionTimestamp() != nil || !meta_util.MustAlreadyReconciled(e.ObjectNew)
+		For(&alertingv1alpha1.Profile{}, builder.WithPredicates(
+			predicate.Funcs{
+				CreateFunc: func(e event.CreateEvent) bool {
+					return controllers.ReconcileOnCreate(e.Object, r.ResyncPeriod)
+				},
+				UpdateFunc: func(e event.UpdateEvent) bool {
+					return (e.ObjectNew.(metav1.Object)).GetDeletionTimestamp() != nil || !meta_util.MustAlreadyReconciled(e.ObjectNew) ||
+						controllers.DryRunChanged(e.ObjectOld, e.ObjectNew)
+				},
 			},
-		}).
-		WithEventFilter(predicate.NewPredicateFuncs(func(e client.Object) bool {
-			if restrictToNamespace != "" && e.GetNamespace() != restrictToNamespace {
-				klog.Infof("Only %s namespace is supported for Kubeform Community. Please upgrade to Kubeform Enterprise to use any namespace.", restrictToNamespace)
-				return false
-			}
-			return true
-		})).
+			predicate.NewPredicateFuncs(func(e client.Object) bool {
+				if restrictToNamespace != "" && e.GetNamespace() != restrictToNamespace {
+					klog.Infof("Only %s namespace is supported for Kubeform Community. Please upgrade to Kubeform Enterprise to use any namespace.", restrictToNamespace)
+					return false
+				}
+				return true
+			}),
+		)).
+		Watches(&source.Kind{Type: &corev1.Secret{}}, controllers.EnqueueSecretReferences(mgr, r.Gvk, &alertingv1alpha1.Profile{}, restrictToNamespace)).
 		Complete(r)
 }
@@ -0,0 +1,126 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the AppsCode Community License 1.0.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://github.com/appscode/licenses/raw/1.0.0/AppsCode-Community-1.0.0.md
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
+	kmapi "kmodules.xyz/client-go/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// SecondaryAPITokenKey is the optional key of the provider secret holding the API token used when
+	// the token of the provider configuration is rejected, e.g. while the tokens are rotated
+	SecondaryAPITokenKey = "secondaryApiToken"
+
+	ConditionAPIToken = "APIToken"
+
+	APITokenPrimary   = "primary"
+	APITokenSecondary = "secondary"
+)
+
+// responses of the Dynatrace API to a revoked, expired or insufficiently scoped API token
+var authErrorPattern = regexp.MustCompile(`(` +
+	regexp.QuoteMeta(http.StatusText(http.StatusUnauthorized)) + `|` +
+	regexp.QuoteMeta(http.StatusText(http.StatusForbidden)) + `) \(` +
+	`|"code": (401|403)\b`)
+
+// isAuthError returns true if the request failed because the API token was rejected
+func isAuthError(err error) bool {
+	return err != nil && authErrorPattern.MatchString(err.Error())
+}
+
+// selectAPIToken returns the provider configuration using the primary API token, or the secondary API token
+// if the environment rejects the primary one and accepts the secondary one. No token is returned when the
+// configuration has no secondary API token.
+func selectAPIToken(ctx context.Context, mapData map[string]interface{}, secondaryToken string) (map[string]interface{}, string) {
+	if secondaryToken == "" {
+		return mapData, ""
+	}
+
+	envURL := fmt.Sprint(mapData["dt_env_url"])
+	err := CheckEnvironment(ctx, envURL, fmt.Sprint(mapData["dt_api_token"]))
+	if !isAuthError(err) {
+		return mapData, APITokenPrimary
+	}
+	if err := CheckEnvironment(ctx, envURL, secondaryToken); err != nil {
+		return mapData, APITokenPrimary
+	}
+
+	secondaryData := make(map[string]interface{}, len(mapData))
+	for k, v := range mapData {
+		secondaryData[k] = v
+	}
+	secondaryData["dt_api_token"] = secondaryToken
+	return secondaryData, APITokenSecondary
+}
+
+// setAPITokenCondition publishes which API token of the provider configuration is used for the object,
+// and warns that the primary API token is rejected when the secondary one is used
+func setAPITokenCondition(rClient client.Client, recorder record.EventRecorder, ctx context.Context, gv schema.GroupVersion, obj *unstructured.Unstructured, token string) error {
+	if token == APITokenSecondary {
+		warnSecondaryAPIToken(recorder, obj)
+	}
+
+	objGen, _, err := unstructured.NestedInt64(obj.Object, "metadata", "generation")
+	if err != nil {
+		return err
+	}
+
+	conditions, err := getConditions(gv, obj)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case token == APITokenPrimary && !kmapi.IsConditionTrue(conditions, ConditionAPIToken):
+		conditions = kmapi.SetCondition(conditions, kmapi.NewCondition(ConditionAPIToken, "The primary API token is used", objGen, true))
+	case token == APITokenSecondary && !kmapi.IsConditionFalse(conditions, ConditionAPIToken):
+		conditions = kmapi.SetCondition(conditions, kmapi.NewCondition(ConditionAPIToken, "The primary API token was rejected, the secondary API token is used", objGen, false))
+	case token == "" && kmapi.HasCondition(conditions, ConditionAPIToken):
+		conditions = kmapi.RemoveCondition(conditions, ConditionAPIToken)
+	default:
+		return nil
+	}
+
+	err = setNestedFieldNoCopy(obj.Object, conditions, "status", "conditions")
+	if err != nil {
+		return err
+	}
+	return rClient.Status().Update(ctx, obj)
+}
+
+// warnSecondaryAPIToken publishes a warning event on the object using the secondary API token, so that the
+// rotation of the primary API token gets completed before the secondary one is revoked too
+func warnSecondaryAPIToken(recorder record.EventRecorder, obj runtime.Object) {
+	recorder.Event(obj, corev1.EventTypeWarning, EventReasonSecondaryAPIToken, "The primary API token was rejected, the secondary API token is used")
+}
+
+// getSecondaryAPIToken returns the secondary API token of the provider secret of the object, if any
+func getSecondaryAPIToken(providerSecretData map[string][]byte) string {
+	return strings.TrimSpace(string(providerSecretData[SecondaryAPITokenKey]))
+}
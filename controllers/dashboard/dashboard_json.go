@@ -0,0 +1,254 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the AppsCode Community License 1.0.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://github.com/appscode/licenses/raw/1.0.0/AppsCode-Community-1.0.0.md
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dashboard
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	dbs "github.com/dtcookie/dynatrace/api/config/dashboards"
+	"github.com/dtcookie/hcl"
+	"github.com/hashicorp/go-cty/cty/msgpack"
+	tfschema "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	jsoniter "github.com/json-iterator/go"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	dashboardv1alpha1 "kubeform.dev/provider-dynatrace-api/apis/dashboard/v1alpha1"
+	"kubeform.dev/provider-dynatrace-controller/controllers"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+)
+
+const (
+	// DashboardJSONKey holds the native Dynatrace dashboard JSON of the object
+	DashboardJSONKey = "dynatrace.kubeform.com/dashboard-json"
+	// DashboardJSONFromKey references the ConfigMap holding the native Dynatrace dashboard JSON
+	// of the object, as <name> or <name>/<key>
+	DashboardJSONFromKey = "dynatrace.kubeform.com/dashboard-json-from"
+
+	defaultDashboardJSONKey = "dashboard.json"
+
+	// dashboardJSONFromIndex indexes the dashboards by the name of the ConfigMap holding their dashboard JSON
+	dashboardJSONFromIndex = "metadata.annotations." + DashboardJSONFromKey
+)
+
+// dashboardJSONRef returns the name of the ConfigMap and the key holding the dashboard JSON of the object
+func dashboardJSONRef(obj client.Object) (string, string, bool) {
+	ref, ok := obj.GetAnnotations()[DashboardJSONFromKey]
+	if !ok {
+		return "", "", false
+	}
+	name, key := ref, defaultDashboardJSONKey
+	if i := strings.Index(ref, "/"); i >= 0 {
+		name, key = ref[:i], ref[i+1:]
+	}
+	return name, key, true
+}
+
+// dashboardJSONChanged returns true if the update changed the dashboard JSON of the object or its reference,
+// which only changes the metadata of the object
+func dashboardJSONChanged(oldObj, newObj client.Object) bool {
+	for _, key := range []string{DashboardJSONKey, DashboardJSONFromKey} {
+		oldVal, oldOk := oldObj.GetAnnotations()[key]
+		newVal, newOk := newObj.GetAnnotations()[key]
+		if oldOk != newOk || oldVal != newVal {
+			return true
+		}
+	}
+	return false
+}
+
+// setupDashboardJSONIndex indexes the dashboards by the ConfigMap holding their dashboard JSON
+func setupDashboardJSONIndex(ctx context.Context, mgr ctrl.Manager) error {
+	return mgr.GetFieldIndexer().IndexField(ctx, &dashboardv1alpha1.Dashboard{}, dashboardJSONFromIndex, func(o client.Object) []string {
+		name, _, ok := dashboardJSONRef(o)
+		if !ok || name == "" {
+			return nil
+		}
+		return []string{name}
+	})
+}
+
+// enqueueDashboardJSONReferences returns the handler enqueueing the dashboards whose dashboard JSON is held by
+// the changed ConfigMap, so that edits of the JSON are applied without waiting for a resync
+func enqueueDashboardJSONReferences(mgr ctrl.Manager, restrictToNamespace string) handler.EventHandler {
+	rClient := mgr.GetClient()
+
+	return handler.EnqueueRequestsFromMapFunc(func(cm client.Object) []ctrl.Request {
+		if restrictToNamespace != "" && cm.GetNamespace() != restrictToNamespace {
+			return nil
+		}
+
+		var list dashboardv1alpha1.DashboardList
+		err := rClient.List(context.TODO(), &list, client.InNamespace(cm.GetNamespace()), client.MatchingFields{dashboardJSONFromIndex: cm.GetName()})
+		if err != nil {
+			klog.Errorf("unable to list Dashboard referencing configmap %s/%s: %v", cm.GetNamespace(), cm.GetName(), err)
+			return nil
+		}
+
+		requests := make([]ctrl.Request, 0, len(list.Items))
+		for _, item := range list.Items {
+			requests = append(requests, ctrl.Request{NamespacedName: types.NamespacedName{Namespace: item.Namespace, Name: item.Name}})
+		}
+		return requests
+	})
+}
+
+// applyDashboardJSON translates the native dashboard JSON referenced by the annotations of the object
+// into spec.resource, so that the dashboard is created and updated from it
+func applyDashboardJSON(rClient client.Client, ctx context.Context, obj *unstructured.Unstructured, jsonit jsoniter.API) error {
+	if obj.GetDeletionTimestamp() != nil {
+		return nil
+	}
+
+	data, found, err := getDashboardJSON(rClient, ctx, obj)
+	if err != nil || !found {
+		return err
+	}
+
+	resource, err := DashboardJSONToResource(data, jsonit)
+	if err != nil {
+		return fmt.Errorf("failed to translate the dashboard json: %v", err)
+	}
+	// the id in the json belongs to the environment it was exported from
+	id, _, err := unstructured.NestedString(obj.Object, "spec", "resource", "id")
+	if err != nil {
+		return err
+	}
+	resource.ID = id
+
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(resource)
+	if err != nil {
+		return err
+	}
+	current, _, err := unstructured.NestedMap(obj.Object, "spec", "resource")
+	if err != nil {
+		return err
+	}
+	if equality.Semantic.DeepEqual(current, content) {
+		return nil
+	}
+
+	err = unstructured.SetNestedMap(obj.Object, content, "spec", "resource")
+	if err != nil {
+		return err
+	}
+	klog.Infof("Dashboard %s/%s updated from the dashboard json", obj.GetNamespace(), obj.GetName())
+	return rClient.Update(ctx, obj)
+}
+
+// getDashboardJSON returns the native dashboard JSON of the object, either from the annotation or the referenced ConfigMap
+func getDashboardJSON(rClient client.Client, ctx context.Context, obj *unstructured.Unstructured) ([]byte, bool, error) {
+	annotations := obj.GetAnnotations()
+	if val, ok := annotations[DashboardJSONKey]; ok {
+		return []byte(val), true, nil
+	}
+
+	name, key, ok := dashboardJSONRef(obj)
+	if !ok {
+		return nil, false, nil
+	}
+
+	var cm corev1.ConfigMap
+	req := types.NamespacedName{
+		Namespace: obj.GetNamespace(),
+		Name:      name,
+	}
+	if err := rClient.Get(ctx, req, &cm); err != nil {
+		return nil, false, err
+	}
+	if val, ok := cm.Data[key]; ok {
+		return []byte(val), true, nil
+	}
+	if val, ok := cm.BinaryData[key]; ok {
+		return val, true, nil
+	}
+	return nil, false, fmt.Errorf("key %s not found in ConfigMap %s/%s", key, obj.GetNamespace(), name)
+}
+
+// DashboardJSONToResource converts a dashboard in the native Dynatrace JSON format, as exported from the
+// Dynatrace UI or API, into the resource of a Dashboard
+func DashboardJSONToResource(data []byte, jsonit jsoniter.API) (*dashboardv1alpha1.DashboardSpecResource, error) {
+	config := new(dbs.Dashboard)
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, err
+	}
+	config.ID = nil
+	config.ConfigurationMetadata = nil
+
+	marshalled, err := config.MarshalHCL()
+	if err != nil {
+		return nil, err
+	}
+	stateByte, err := json.Marshal(marshalled)
+	if err != nil {
+		return nil, err
+	}
+
+	resource := new(dashboardv1alpha1.DashboardSpecResource)
+	err = jsonit.Unmarshal(stateByte, resource)
+	if err != nil {
+		return nil, err
+	}
+	return resource, nil
+}
+
+// ResourceToDashboardJSON converts the resource of a Dashboard into the native Dynatrace JSON format,
+// which can be imported with the Dynatrace UI or API
+func ResourceToDashboardJSON(resource *dashboardv1alpha1.DashboardSpecResource, res *tfschema.Resource, jsonit jsoniter.API) ([]byte, error) {
+	str, err := jsonit.Marshal(resource)
+	if err != nil {
+		return nil, err
+	}
+	rawSpec := make(map[string]interface{})
+	err = json.Unmarshal(str, &rawSpec)
+	if err != nil {
+		return nil, err
+	}
+	rawSpec["id"] = resource.ID
+
+	schma := res.CoreConfigSchema()
+	stateMP, err := msgpack.Marshal(controllers.HCL2ValueFromConfigValue(rawSpec), schma.ImpliedType())
+	if err != nil {
+		return nil, err
+	}
+	stateVal, err := msgpack.Unmarshal(stateMP, schma.ImpliedType())
+	if err != nil {
+		return nil, err
+	}
+	state, err := res.ShimInstanceStateFromValue(stateVal)
+	if err != nil {
+		return nil, err
+	}
+
+	config := new(dbs.Dashboard)
+	if err := config.UnmarshalHCL(hcl.DecoderFrom(res.Data(state))); err != nil {
+		return nil, err
+	}
+	config.ConfigurationMetadata = nil
+	if resource.ID != "" {
+		config.ID = &resource.ID
+	}
+	return json.MarshalIndent(config, "", "  ")
+}
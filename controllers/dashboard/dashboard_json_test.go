@@ -0,0 +1,114 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the AppsCode Community License 1.0.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://github.com/appscode/licenses/raw/1.0.0/AppsCode-Community-1.0.0.md
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dashboard
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	dynatrace "github.com/dynatrace-oss/terraform-provider-dynatrace/provider"
+	dashboardv1alpha1 "kubeform.dev/provider-dynatrace-api/apis/dashboard/v1alpha1"
+	"kubeform.dev/provider-dynatrace-controller/controllers"
+)
+
+// pruneZero removes the false booleans, empty lists and empty objects, which are equal to a missing field for
+// Dynatrace
+func pruneZero(val interface{}) interface{} {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		for key, elem := range v {
+			if elem = pruneZero(elem); elem == nil {
+				delete(v, key)
+			} else {
+				v[key] = elem
+			}
+		}
+		if len(v) == 0 {
+			return nil
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			return nil
+		}
+		for i, elem := range v {
+			v[i] = pruneZero(elem)
+		}
+	case bool:
+		if !v {
+			return nil
+		}
+	}
+	return val
+}
+
+func TestDashboardJSONRoundTrip(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/dashboard.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	jsonit := controllers.GetJSONItr(dashboardv1alpha1.GetEncoder(), dashboardv1alpha1.GetDecoder())
+
+	resource, err := DashboardJSONToResource(data, jsonit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resource.ID != "" {
+		t.Errorf("resource holds the id %s of the exported dashboard", resource.ID)
+	}
+	if resource.DashboardMetadata == nil || resource.DashboardMetadata.Name == nil || *resource.DashboardMetadata.Name != "Shop overview" {
+		t.Fatalf("dashboard metadata = %+v, want the name Shop overview", resource.DashboardMetadata)
+	}
+	if len(resource.Tile) != 5 {
+		t.Fatalf("resource holds %d tiles, want 5", len(resource.Tile))
+	}
+
+	resource.ID = "8f6c7b1e-3a52-4c9d-9a8e-7d0e2c5a1b40"
+	res := dynatrace.Provider().ResourcesMap["dynatrace_dashboard"]
+	out, err := ResourceToDashboardJSON(resource, res, jsonit)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got, want map[string]interface{}
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &want); err != nil {
+		t.Fatal(err)
+	}
+	// the configuration metadata belongs to the environment the dashboard was exported from
+	delete(want, "metadata")
+	if !reflect.DeepEqual(pruneZero(got), pruneZero(want)) {
+		t.Errorf("ResourceToDashboardJSON() = %s, want %s", out, data)
+	}
+
+	// exporting the dashboard again returns the same json
+	again, err := DashboardJSONToResource(out, jsonit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again.ID = resource.ID
+	out2, err := ResourceToDashboardJSON(again, res, jsonit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out2) != string(out) {
+		t.Errorf("second export = %s, want %s", out2, out)
+	}
+}
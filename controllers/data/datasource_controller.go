@@ -0,0 +1,116 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the AppsCode Community License 1.0.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://github.com/appscode/licenses/raw/1.0.0/AppsCode-Community-1.0.0.md
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package data
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	tfschema "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	auditlib "go.bytebuilders.dev/audit/lib"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+	meta_util "kmodules.xyz/client-go/meta"
+	"kubeform.dev/provider-dynatrace-controller/controllers"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// DataSourceReconciler reconciles the objects of a read-only kind backed by a data source
+type DataSourceReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+
+	Gvk        schema.GroupVersionKind // GVK of the Resource
+	Provider   *tfschema.Provider      // returns a *schema.Provider from the provider package
+	DataSource *tfschema.Resource      // returns *schema.Resource of the data source
+	TypeName   string                  // data source type
+
+	ResyncPeriod time.Duration // interval after which the data source is read again
+}
+
+// +kubebuilder:rbac:groups=data.dynatrace.kubeform.com,resources=*,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=data.dynatrace.kubeform.com,resources=*/status,verbs=get;update;patch
+
+func (r *DataSourceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues(r.Gvk.Kind, req.NamespacedName)
+
+	var unstructuredObj unstructured.Unstructured
+	unstructuredObj.SetGroupVersionKind(r.Gvk)
+
+	if err := r.Get(ctx, req.NamespacedName, &unstructuredObj); err != nil {
+		log.Error(err, "unable to fetch "+r.Gvk.Kind)
+		if errors.IsNotFound(err) {
+			controllers.ForgetObjectMetrics(r.Gvk, req.NamespacedName)
+		}
+		// we'll ignore not-found errors, since they can't be fixed by an immediate
+		// requeue (we'll need to wait for a new notification), and we can get them on deleted requests.
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	return controllers.StartDataSourceProcess(r.Client, r.Recorder, r.Provider, ctx, r.DataSource, &unstructuredObj, r.TypeName, r.ResyncPeriod)
+}
+
+func (r *DataSourceReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager, auditor *auditlib.EventPublisher, restrictToNamespace string) error {
+	if auditor != nil {
+		if err := auditor.SetupWithManagerForKind(ctx, mgr, r.Gvk); err != nil {
+			klog.Error(err, "unable to set up auditor", r.Gvk.GroupVersion().String(), r.Gvk.Kind)
+			return err
+		}
+	}
+
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(r.Gvk)
+
+	if err := controllers.SetupSecretIndexes(ctx, mgr, obj); err != nil {
+		klog.Error(err, "unable to set up secret indexes", r.Gvk.GroupVersion().String(), r.Gvk.Kind)
+		return err
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(obj, builder.WithPredicates(
+			predicate.Funcs{
+				CreateFunc: func(e event.CreateEvent) bool {
+					return controllers.ReconcileOnCreate(e.Object, r.ResyncPeriod)
+				},
+				UpdateFunc: func(e event.UpdateEvent) bool {
+					return (e.ObjectNew.(metav1.Object)).GetDeletionTimestamp() != nil || !meta_util.MustAlreadyReconciled(e.ObjectNew)
+				},
+			},
+			predicate.NewPredicateFuncs(func(e client.Object) bool {
+				if restrictToNamespace != "" && e.GetNamespace() != restrictToNamespace {
+					klog.Infof("Only %s namespace is supported for Kubeform Community. Please upgrade to Kubeform Enterprise to use any namespace.", restrictToNamespace)
+					return false
+				}
+				return true
+			}),
+		)).
+		Watches(&source.Kind{Type: &corev1.Secret{}}, controllers.EnqueueSecretReferences(mgr, r.Gvk, obj, restrictToNamespace)).
+		Complete(r)
+}
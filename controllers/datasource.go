@@ -0,0 +1,311 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the AppsCode Community License 1.0.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://github.com/appscode/licenses/raw/1.0.0/AppsCode-Community-1.0.0.md
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gobuffalo/flect"
+	"github.com/hashicorp/go-cty/cty/msgpack"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	tfschema "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/record"
+	kmapi "kmodules.xyz/client-go/api/v1"
+	"sigs.k8s.io/cli-utils/pkg/kstatus/status"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// StartDataSourceProcess reads the data source with the arguments of spec.args and publishes the result in status.output
+func StartDataSourceProcess(rClient client.Client, recorder record.EventRecorder, provider *tfschema.Provider, ctx context.Context, ds *tfschema.Resource, unstructuredObj *unstructured.Unstructured, tName string, resyncPeriod time.Duration) (ctrl.Result, error) {
+	// data sources don't own anything in Dynatrace, so there is nothing to clean up
+	if unstructuredObj.GetDeletionTimestamp() != nil || isKindStopped(unstructuredObj.GroupVersionKind()) {
+		return ctrl.Result{}, nil
+	}
+
+	output, err := readDataSource(rClient, recorder, provider, ctx, ds, unstructuredObj, tName)
+	if isTransientError(err) {
+		// the tenant is throttling the requests or unavailable, try again later instead of failing
+		delay := nextRetryDelay(unstructuredObj.GetUID(), err)
+		recorder.Eventf(unstructuredObj, corev1.EventTypeWarning, EventReasonRetrying, "%s, retrying in %s", err, delay.Round(time.Second))
+		err2 := dataSourceUpdateStatus(rClient, ctx, unstructuredObj, nil, &waitError{
+			condition: ConditionRetrying,
+			msg:       fmt.Sprintf("%s, retrying in %s", err, delay.Round(time.Second)),
+		})
+		if err2 != nil {
+			return ctrl.Result{}, err2
+		}
+		return ctrl.Result{RequeueAfter: delay}, nil
+	}
+	resetRetryDelay(unstructuredObj.GetUID())
+	if err != nil {
+		recorder.Event(unstructuredObj, corev1.EventTypeWarning, EventReasonFailed, err.Error())
+		err2 := dataSourceUpdateStatus(rClient, ctx, unstructuredObj, nil, err)
+		if err2 != nil {
+			return ctrl.Result{}, err2
+		}
+		return ctrl.Result{}, err
+	}
+
+	err = dataSourceUpdateStatus(rClient, ctx, unstructuredObj, output, nil)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// requeue the object so that the published result follows the changes in Dynatrace
+	return ctrl.Result{RequeueAfter: resyncPeriod}, nil
+}
+
+func readDataSource(rClient client.Client, recorder record.EventRecorder, provider *tfschema.Provider, ctx context.Context, ds *tfschema.Resource, unstructuredObj *unstructured.Unstructured, tName string) (output map[string]interface{}, err error) {
+	server, token, err := getProviderServer(rClient, provider, ctx, unstructuredObj)
+	if err != nil {
+		return nil, err
+	}
+	if token == APITokenSecondary {
+		warnSecondaryAPIToken(recorder, unstructuredObj)
+	}
+	defer func() {
+		if isAuthError(err) {
+			// configure a new server on the next reconcile, with the secondary API token if there is one
+			forgetRejectedServer(server)
+		}
+	}()
+
+	args, _, err := unstructured.NestedMap(unstructuredObj.Object, "spec", "args")
+	if err != nil {
+		return nil, err
+	}
+	// numbers of unstructured objects are int64, the conversion to cty expects float64
+	argsByte, err := json.Marshal(args)
+	if err != nil {
+		return nil, err
+	}
+	args = make(map[string]interface{})
+	err = json.Unmarshal(argsByte, &args)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := dataSourceConfig(args, ds.Schema)
+	if err != nil {
+		return nil, err
+	}
+
+	schemaBlock := ds.CoreConfigSchema()
+	configMP, err := msgpack.Marshal(HCL2ValueFromConfigValue(config), schemaBlock.ImpliedType())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := waitForTenant(ctx, server); err != nil {
+		return nil, err
+	}
+	resp, err := server.ReadDataSource(ctx, &tfprotov5.ReadDataSourceRequest{
+		TypeName: tName,
+		Config: &tfprotov5.DynamicValue{
+			MsgPack: configMP,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Diagnostics) > 0 {
+		err = diagToError(resp.Diagnostics)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	stateVal, err := msgpack.Unmarshal(resp.State.MsgPack, schemaBlock.ImpliedType())
+	if err != nil {
+		return nil, err
+	}
+	state := terraform.NewResourceConfigShimmed(stateVal, schemaBlock).Raw
+
+	outputByte, err := json.Marshal(dataSourceOutput(state, ds.Schema))
+	if err != nil {
+		return nil, err
+	}
+	output = make(map[string]interface{})
+	err = json.Unmarshal(outputByte, &output)
+	if err != nil {
+		return nil, err
+	}
+
+	return output, nil
+}
+
+// DataSourceFieldName returns the name of the field of the object for the terraform attribute
+func DataSourceFieldName(attr string) string {
+	if attr == "id" {
+		return attr
+	}
+	return flect.Camelize(attr)
+}
+
+// dataSourceFieldPath returns the path of the field in the object for the attribute path of the data source
+func dataSourceFieldPath(steps []string) string {
+	path := "spec.args"
+	for _, step := range steps {
+		if _, err := strconv.Atoi(step); err == nil || step == "*" {
+			path += "[" + step + "]"
+		} else {
+			path += "." + DataSourceFieldName(step)
+		}
+	}
+	return path
+}
+
+// dataSourceConfig converts the arguments of the object into the configuration of the data source.
+// Every attribute of the schema is set, the ones which are not given are null.
+func dataSourceConfig(args map[string]interface{}, s map[string]*tfschema.Schema) (map[string]interface{}, error) {
+	known := make(map[string]bool)
+	config := make(map[string]interface{})
+	for attr, sch := range s {
+		field := DataSourceFieldName(attr)
+		known[field] = true
+
+		val, ok := args[field]
+		if !ok || val == nil {
+			config[attr] = nil
+			continue
+		}
+
+		elem, isBlock := sch.Elem.(*tfschema.Resource)
+		if !isBlock || (sch.Type != tfschema.TypeList && sch.Type != tfschema.TypeSet) {
+			config[attr] = val
+			continue
+		}
+
+		items, ok := val.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s must be a list", field)
+		}
+		var blocks []interface{}
+		for _, item := range items {
+			m, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("items of %s must be objects", field)
+			}
+			block, err := dataSourceConfig(m, elem.Schema)
+			if err != nil {
+				return nil, err
+			}
+			blocks = append(blocks, block)
+		}
+		config[attr] = blocks
+	}
+
+	for field := range args {
+		if !known[field] {
+			return nil, fmt.Errorf("unknown argument %s", field)
+		}
+	}
+	return config, nil
+}
+
+// dataSourceOutput converts the state of the data source into the fields of status.output
+func dataSourceOutput(state map[string]interface{}, s map[string]*tfschema.Schema) map[string]interface{} {
+	output := make(map[string]interface{})
+	for attr, val := range state {
+		sch, ok := s[attr]
+		if !ok {
+			continue
+		}
+
+		elem, isBlock := sch.Elem.(*tfschema.Resource)
+		items, isList := val.([]interface{})
+		if isBlock && isList {
+			var blocks []interface{}
+			for _, item := range items {
+				if m, ok := item.(map[string]interface{}); ok {
+					blocks = append(blocks, dataSourceOutput(m, elem.Schema))
+				}
+			}
+			val = blocks
+		}
+		output[DataSourceFieldName(attr)] = val
+	}
+	return output
+}
+
+func dataSourceUpdateStatus(rClient client.Client, ctx context.Context, obj *unstructured.Unstructured, output map[string]interface{}, er error) error {
+	objGen := obj.GetGeneration()
+
+	var conditions []kmapi.Condition
+	var diags []diagnostic
+	phase := status.CurrentStatus
+	if werr, ok := er.(*waitError); ok {
+		// the last output is kept until the data source can be read again
+		conditions = kmapi.SetCondition(conditions, kmapi.NewCondition(werr.condition, werr.msg, objGen))
+		phase = status.InProgressStatus
+	} else if er != nil {
+		conditions = kmapi.SetCondition(conditions, kmapi.NewCondition("Stalled", er.Error(), objGen))
+		diags = getDiagnostics(er, dataSourceFieldPath)
+		if len(diags) > 0 {
+			conditions = kmapi.SetCondition(conditions, diagnosticsCondition(diags, objGen))
+		}
+		phase = status.FailedStatus
+	}
+	if err := setDiagnostics(rClient, ctx, obj, diags); err != nil {
+		return err
+	}
+
+	// store the conditions in the same form as they are read back from the api server
+	condByte, err := json.Marshal(conditions)
+	if err != nil {
+		return err
+	}
+	var condList []interface{}
+	err = json.Unmarshal(condByte, &condList)
+	if err != nil {
+		return err
+	}
+
+	err = setNestedFieldNoCopy(obj.Object, condList, "status", "conditions")
+	if err != nil {
+		return err
+	}
+	if er == nil {
+		err = setNestedFieldNoCopy(obj.Object, output, "status", "output")
+		if err != nil {
+			return err
+		}
+		err = setNestedFieldNoCopy(obj.Object, objGen, "status", "observedGeneration")
+		if err != nil {
+			return err
+		}
+	}
+	err = setNestedFieldNoCopy(obj.Object, string(phase), "status", "phase")
+	if err != nil {
+		return err
+	}
+
+	err = rClient.Status().Update(ctx, obj)
+	if err != nil {
+		return err
+	}
+	setPhaseMetric(obj, phase)
+	return nil
+}
@@ -0,0 +1,60 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the AppsCode Community License 1.0.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://github.com/appscode/licenses/raw/1.0.0/AppsCode-Community-1.0.0.md
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/record"
+)
+
+const (
+	EventReasonCreated           = "Created"
+	EventReasonUpdated           = "Updated"
+	EventReasonReplaced          = "Replaced"
+	EventReasonDestroyed         = "Destroyed"
+	EventReasonOrphaned          = "Orphaned"
+	EventReasonDriftDetected     = "DriftDetected"
+	EventReasonFailed            = "Failed"
+	EventReasonRetrying          = "Retrying"
+	EventReasonSecondaryAPIToken = "SecondaryAPIToken"
+)
+
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// resultEvents are the events of the results of a reconcile which changed the object in Dynatrace
+var resultEvents = map[string]struct {
+	reason  string
+	message string
+}{
+	ResultCreated:   {EventReasonCreated, "Created %s %s in Dynatrace"},
+	ResultUpdated:   {EventReasonUpdated, "Updated %s %s in Dynatrace"},
+	ResultReplaced:  {EventReasonReplaced, "Replaced %s in Dynatrace, a changed field can't be updated in place, the new id is %s"},
+	ResultDestroyed: {EventReasonDestroyed, "Destroyed %s %s in Dynatrace"},
+	ResultOrphaned:  {EventReasonOrphaned, "Kept %s %s in Dynatrace because of the termination policy"},
+}
+
+// publishResult publishes the event of the result of the reconcile, nothing is published if the object
+// was left unchanged in Dynatrace
+func publishResult(recorder record.EventRecorder, obj *unstructured.Unstructured, result string) {
+	ev, ok := resultEvents[result]
+	if !ok {
+		return
+	}
+	id, _, _ := unstructured.NestedString(obj.Object, "spec", "resource", "id")
+	recorder.Eventf(obj, corev1.EventTypeNormal, ev.reason, ev.message, obj.GetKind(), id)
+}
@@ -0,0 +1,50 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the AppsCode Community License 1.0.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://github.com/appscode/licenses/raw/1.0.0/AppsCode-Community-1.0.0.md
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// stoppedKinds holds the versions of the kinds whose CRD was removed or which are no longer served. The
+// controllers can't be removed from a running manager, so the objects of a stopped kind are ignored instead.
+var stoppedKinds = struct {
+	sync.RWMutex
+	mp map[schema.GroupVersionKind]bool
+}{mp: make(map[schema.GroupVersionKind]bool)}
+
+// StopKind makes the controller of the kind ignore its objects
+func StopKind(gvk schema.GroupVersionKind) {
+	stoppedKinds.Lock()
+	defer stoppedKinds.Unlock()
+	stoppedKinds.mp[gvk] = true
+}
+
+// StartKind makes the controller of the kind reconcile its objects again, e.g. when its CRD is reinstalled
+func StartKind(gvk schema.GroupVersionKind) {
+	stoppedKinds.Lock()
+	defer stoppedKinds.Unlock()
+	delete(stoppedKinds.mp, gvk)
+}
+
+func isKindStopped(gvk schema.GroupVersionKind) bool {
+	stoppedKinds.RLock()
+	defer stoppedKinds.RUnlock()
+	return stoppedKinds.mp[gvk]
+}
@@ -0,0 +1,457 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the AppsCode Community License 1.0.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://github.com/appscode/licenses/raw/1.0.0/AppsCode-Community-1.0.0.md
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/klog/v2"
+	"kubeform.dev/terraform-backend-sdk/states/remote"
+	"kubeform.dev/terraform-backend-sdk/states/statemgr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// The state is stored the way the kubernetes backend of Terraform stores it, so that Terraform can be run
+// against the same state, unless the state is chunked
+const (
+	KubernetesBackend = "kubernetes"
+
+	tfstateKey                = "tfstate"
+	tfstateSecretSuffixKey    = "tfstateSecretSuffix"
+	tfstateWorkspaceKey       = "tfstateWorkspace"
+	tfstateLockInfoAnnotation = "app.terraform.io/lock-info"
+
+	// StateChunksKey holds the number of secrets the state is split in, when it is larger than the chunk size
+	StateChunksKey = "dynatrace.kubeform.com/state-chunks"
+	// StateChecksumKey holds the md5 checksum of a chunked state, to detect a state read while it is written
+	StateChecksumKey = "dynatrace.kubeform.com/state-md5"
+
+	defaultWorkspace = "default"
+)
+
+// +kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,verbs=get;create;update
+
+// apiReader reads the state and the locks from the api server, set by SetupKubernetesBackend
+var apiReader client.Reader
+
+// SetupKubernetesBackend sets the reader of the kubernetes backend. The state and the locks are read from the
+// api server rather than from the cache of the manager, which may not have seen the last write yet.
+func SetupKubernetesBackend(reader client.Reader) {
+	apiReader = reader
+}
+
+// kubernetesBackendConfig is the configuration of the kubernetes backend in the backend secret, e.g.
+// {"secret_suffix": "dashboards", "compress": true, "chunk_size": 512000}
+type kubernetesBackendConfig struct {
+	// SecretSuffix is the suffix of the secret holding the state, tfstate-<workspace>-<suffix>
+	SecretSuffix string `json:"secret_suffix"`
+	// Workspace is the Terraform workspace of the state, default by default
+	Workspace string `json:"workspace,omitempty"`
+	// Labels are added to the secrets and the lease of the state
+	Labels map[string]string `json:"labels,omitempty"`
+	// Compress stores the state gzipped, true by default
+	Compress *bool `json:"compress,omitempty"`
+	// ChunkSize is the maximum size of the state stored in a secret, larger states are split in several
+	// secrets. The state isn't split by default.
+	ChunkSize int `json:"chunk_size,omitempty"`
+}
+
+// kubernetesClient stores the state in secrets in the namespace of the object, and locks it with a lease
+type kubernetesClient struct {
+	client     client.Client
+	reader     client.Reader
+	ctx        context.Context
+	namespace  string
+	secretName string
+	leaseName  string
+	labels     map[string]string
+	compress   bool
+	chunkSize  int
+}
+
+var _ remote.ClientLocker = &kubernetesClient{}
+
+func newKubernetesClient(rClient client.Client, ctx context.Context, namespace string, data []byte) (*kubernetesClient, error) {
+	var config kubernetesBackendConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("invalid configuration of the %s backend: %v", KubernetesBackend, err)
+	}
+	if config.SecretSuffix == "" {
+		return nil, fmt.Errorf("secret_suffix of the %s backend is required", KubernetesBackend)
+	}
+	if config.Workspace == "" {
+		config.Workspace = defaultWorkspace
+	}
+	if config.ChunkSize < 0 {
+		return nil, fmt.Errorf("chunk_size of the %s backend must not be negative", KubernetesBackend)
+	}
+
+	secretName := "tfstate-" + config.Workspace + "-" + config.SecretSuffix
+	if errs := validation.IsDNS1123Subdomain(secretName); len(errs) > 0 {
+		return nil, fmt.Errorf("invalid secret name %s of the %s backend: %s", secretName, KubernetesBackend, strings.Join(errs, ", "))
+	}
+
+	labels := map[string]string{
+		tfstateKey:                     "true",
+		tfstateSecretSuffixKey:         config.SecretSuffix,
+		tfstateWorkspaceKey:            config.Workspace,
+		"app.kubernetes.io/managed-by": "kubeform.com",
+	}
+	for k, v := range config.Labels {
+		labels[k] = v
+	}
+
+	reader := apiReader
+	if reader == nil {
+		reader = rClient
+	}
+	return &kubernetesClient{
+		client:     rClient,
+		reader:     reader,
+		ctx:        ctx,
+		namespace:  namespace,
+		secretName: secretName,
+		leaseName:  "lock-" + secretName,
+		labels:     labels,
+		compress:   config.Compress == nil || *config.Compress,
+		chunkSize:  config.ChunkSize,
+	}, nil
+}
+
+func (c *kubernetesClient) Get() (*remote.Payload, error) {
+	secret, err := c.getSecret(c.secretName)
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	data, ok := secret.Data[tfstateKey]
+	if !ok {
+		return nil, nil
+	}
+
+	chunks := chunkCount(secret)
+	for i := 1; i < chunks; i++ {
+		part, err := c.getSecret(c.chunkName(i))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read chunk %d of the state: %v", i, err)
+		}
+		data = append(data, part.Data[tfstateKey]...)
+	}
+
+	if isGzipped(data) {
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		data, err = ioutil.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sum := md5.Sum(data)
+	if chunks > 1 && secret.Annotations[StateChecksumKey] != hex.EncodeToString(sum[:]) {
+		return nil, fmt.Errorf("the state in secret %s/%s doesn't match its checksum, it is being written", c.namespace, c.secretName)
+	}
+	return &remote.Payload{
+		Data: data,
+		MD5:  sum[:],
+	}, nil
+}
+
+func (c *kubernetesClient) Put(data []byte) error {
+	sum := md5.Sum(data)
+	payload := data
+	if c.compress {
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+		if err := w.Close(); err != nil {
+			return err
+		}
+		payload = buf.Bytes()
+	}
+
+	var chunks [][]byte
+	for c.chunkSize > 0 && len(payload) > c.chunkSize {
+		chunks = append(chunks, payload[:c.chunkSize])
+		payload = payload[c.chunkSize:]
+	}
+	chunks = append(chunks, payload)
+
+	previous := 1
+	if secret, err := c.getSecret(c.secretName); err == nil {
+		previous = chunkCount(secret)
+	} else if !errors.IsNotFound(err) {
+		return err
+	}
+
+	// the chunks are written before the secret referencing them, and the stale chunks are deleted after it
+	for i := 1; i < len(chunks); i++ {
+		if err := c.putSecret(c.chunkName(i), chunks[i], nil); err != nil {
+			return err
+		}
+	}
+	annotations := map[string]string{}
+	if len(chunks) > 1 {
+		annotations[StateChunksKey] = strconv.Itoa(len(chunks))
+		annotations[StateChecksumKey] = hex.EncodeToString(sum[:])
+	}
+	if err := c.putSecret(c.secretName, chunks[0], annotations); err != nil {
+		return err
+	}
+	for i := len(chunks); i < previous; i++ {
+		if err := c.deleteSecret(c.chunkName(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *kubernetesClient) Delete() error {
+	secret, err := c.getSecret(c.secretName)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for i := 1; i < chunkCount(secret); i++ {
+		if err := c.deleteSecret(c.chunkName(i)); err != nil {
+			return err
+		}
+	}
+	return c.deleteSecret(c.secretName)
+}
+
+// Lock locks the state with the lease of the state. The lease is held for the lease duration of the state
+// locks, and a lease held longer is taken over, e.g. when a replica crashed while holding it. A lease without
+// a duration, as taken by Terraform, is held until it is unlocked. It can be released with
+// `terraform force-unlock <lock id>`, or by removing spec.holderIdentity of the lease.
+func (c *kubernetesClient) Lock(info *statemgr.LockInfo) (string, error) {
+	info.Path = c.namespace + "/" + c.secretName
+
+	now := time.Now()
+	leaseSeconds := int32(stateLockLeaseDuration.Seconds())
+	spec := coordinationv1.LeaseSpec{
+		HolderIdentity:       &info.ID,
+		LeaseDurationSeconds: &leaseSeconds,
+		AcquireTime:          &metav1.MicroTime{Time: now},
+		RenewTime:            &metav1.MicroTime{Time: now},
+	}
+
+	var lease coordinationv1.Lease
+	err := c.reader.Get(c.ctx, types.NamespacedName{Namespace: c.namespace, Name: c.leaseName}, &lease)
+	if errors.IsNotFound(err) {
+		lease = coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      c.leaseName,
+				Namespace: c.namespace,
+				Labels:    c.labels,
+				Annotations: map[string]string{
+					tfstateLockInfoAnnotation: string(info.Marshal()),
+				},
+			},
+			Spec: spec,
+		}
+		err = c.client.Create(c.ctx, &lease)
+		if errors.IsAlreadyExists(err) {
+			return "", c.lockError(err)
+		}
+		if err != nil {
+			return "", err
+		}
+		return info.ID, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if lease.Spec.HolderIdentity != nil && *lease.Spec.HolderIdentity != "" {
+		if !leaseExpired(&lease, now) {
+			return "", c.lockErrorFromLease(&lease, fmt.Errorf("the state %s is locked", info.Path))
+		}
+		// the update fails with a conflict if someone else takes over the lease at the same time
+		klog.Warningf("taking over the lock %s of the state %s, held for more than %ds", *lease.Spec.HolderIdentity, info.Path, *lease.Spec.LeaseDurationSeconds)
+	}
+
+	if lease.Annotations == nil {
+		lease.Annotations = map[string]string{}
+	}
+	lease.Annotations[tfstateLockInfoAnnotation] = string(info.Marshal())
+	lease.Spec = spec
+	err = c.client.Update(c.ctx, &lease)
+	if errors.IsConflict(err) {
+		return "", c.lockError(err)
+	}
+	if err != nil {
+		return "", err
+	}
+	return info.ID, nil
+}
+
+func (c *kubernetesClient) Unlock(id string) error {
+	var lease coordinationv1.Lease
+	err := c.reader.Get(c.ctx, types.NamespacedName{Namespace: c.namespace, Name: c.leaseName}, &lease)
+	if err != nil {
+		return err
+	}
+
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity == "" {
+		return fmt.Errorf("the state %s/%s is already unlocked", c.namespace, c.secretName)
+	}
+	if *lease.Spec.HolderIdentity != id {
+		return c.lockErrorFromLease(&lease, fmt.Errorf("lock id %q does not match the lock of the state %s/%s", id, c.namespace, c.secretName))
+	}
+
+	delete(lease.Annotations, tfstateLockInfoAnnotation)
+	lease.Spec = coordinationv1.LeaseSpec{}
+	return c.client.Update(c.ctx, &lease)
+}
+
+// leaseExpired returns true if the lease is held longer than its duration. A lease without a duration never
+// expires.
+func leaseExpired(lease *coordinationv1.Lease, now time.Time) bool {
+	if lease.Spec.LeaseDurationSeconds == nil {
+		return false
+	}
+	renewTime := lease.Spec.RenewTime
+	if renewTime == nil {
+		renewTime = lease.Spec.AcquireTime
+	}
+	if renewTime == nil {
+		return false
+	}
+	return now.After(renewTime.Add(time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second))
+}
+
+// lockError returns the error of a lock taken by someone else at the same time
+func (c *kubernetesClient) lockError(err error) error {
+	var lease coordinationv1.Lease
+	if err2 := c.reader.Get(c.ctx, types.NamespacedName{Namespace: c.namespace, Name: c.leaseName}, &lease); err2 != nil {
+		return &statemgr.LockError{Err: err}
+	}
+	return c.lockErrorFromLease(&lease, err)
+}
+
+func (c *kubernetesClient) lockErrorFromLease(lease *coordinationv1.Lease, err error) error {
+	lockErr := &statemgr.LockError{Err: err}
+	info := &statemgr.LockInfo{}
+	if json.Unmarshal([]byte(lease.Annotations[tfstateLockInfoAnnotation]), info) == nil {
+		lockErr.Info = info
+	}
+	return lockErr
+}
+
+func (c *kubernetesClient) chunkName(i int) string {
+	return c.secretName + "-part-" + strconv.Itoa(i)
+}
+
+func (c *kubernetesClient) getSecret(name string) (*corev1.Secret, error) {
+	var secret corev1.Secret
+	err := c.reader.Get(c.ctx, types.NamespacedName{Namespace: c.namespace, Name: name}, &secret)
+	if err != nil {
+		return nil, err
+	}
+	return &secret, nil
+}
+
+func (c *kubernetesClient) putSecret(name string, data []byte, annotations map[string]string) error {
+	secret, err := c.getSecret(name)
+	if errors.IsNotFound(err) {
+		return c.client.Create(c.ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   c.namespace,
+				Labels:      c.labels,
+				Annotations: annotations,
+			},
+			Type: corev1.SecretTypeOpaque,
+			Data: map[string][]byte{
+				tfstateKey: data,
+			},
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+	delete(secret.Annotations, StateChunksKey)
+	delete(secret.Annotations, StateChecksumKey)
+	for k, v := range annotations {
+		secret.Annotations[k] = v
+	}
+	if secret.Labels == nil {
+		secret.Labels = map[string]string{}
+	}
+	for k, v := range c.labels {
+		secret.Labels[k] = v
+	}
+	secret.Data = map[string][]byte{
+		tfstateKey: data,
+	}
+	return c.client.Update(c.ctx, secret)
+}
+
+func (c *kubernetesClient) deleteSecret(name string) error {
+	err := c.client.Delete(c.ctx, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: c.namespace,
+		},
+	})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// chunkCount returns the number of secrets the state of the secret is split in
+func chunkCount(secret *corev1.Secret) int {
+	n, err := strconv.Atoi(secret.Annotations[StateChunksKey])
+	if err != nil || n < 1 {
+		return 1
+	}
+	return n
+}
+
+func isGzipped(data []byte) bool {
+	return len(data) > 2 && data[0] == 0x1f && data[1] == 0x8b
+}
@@ -0,0 +1,201 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the AppsCode Community License 1.0.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://github.com/appscode/licenses/raw/1.0.0/AppsCode-Community-1.0.0.md
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"kubeform.dev/terraform-backend-sdk/states/statemgr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newFakeKubernetesClient(t *testing.T, config string) (*kubernetesClient, client.Client) {
+	rClient := fake.NewClientBuilder().Build()
+	c, err := newKubernetesClient(rClient, context.Background(), "default", []byte(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, rClient
+}
+
+// randomState returns data which gzip can't compress, so that the compressed state is chunked as well
+func randomState(size int) []byte {
+	data := make([]byte, size)
+	rand.New(rand.NewSource(int64(size))).Read(data)
+	return data
+}
+
+func secretExists(rClient client.Client, name string) bool {
+	var secret corev1.Secret
+	err := rClient.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: name}, &secret)
+	return err == nil
+}
+
+func TestKubernetesClientChunks(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+	}{
+		{name: "uncompressed", config: `{"secret_suffix": "test", "compress": false, "chunk_size": 100}`},
+		{name: "compressed", config: `{"secret_suffix": "test", "chunk_size": 100}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, rClient := newFakeKubernetesClient(t, tt.config)
+
+			// split in tfstate-default-test, -part-1 and -part-2
+			state := randomState(250)
+			if err := c.Put(state); err != nil {
+				t.Fatal(err)
+			}
+			secret, err := c.getSecret(c.secretName)
+			if err != nil {
+				t.Fatal(err)
+			}
+			chunks := chunkCount(secret)
+			if chunks < 3 {
+				t.Fatalf("state of %d bytes is split in %d secrets, want at least 3", len(state), chunks)
+			}
+			for i := 1; i < chunks; i++ {
+				if !secretExists(rClient, c.chunkName(i)) {
+					t.Errorf("chunk %d of the state is missing", i)
+				}
+			}
+			payload, err := c.Get()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(payload.Data, state) {
+				t.Error("Get() doesn't return the chunked state")
+			}
+
+			// shrinking the state deletes the chunks which are no longer used
+			state = randomState(50)
+			if err := c.Put(state); err != nil {
+				t.Fatal(err)
+			}
+			secret, err = c.getSecret(c.secretName)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := secret.Annotations[StateChunksKey]; ok {
+				t.Errorf("%s is kept on a state which isn't chunked", StateChunksKey)
+			}
+			if _, ok := secret.Annotations[StateChecksumKey]; ok {
+				t.Errorf("%s is kept on a state which isn't chunked", StateChecksumKey)
+			}
+			for i := 1; i < chunks; i++ {
+				if secretExists(rClient, c.chunkName(i)) {
+					t.Errorf("stale chunk %d of the state is not deleted", i)
+				}
+			}
+			payload, err = c.Get()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(payload.Data, state) {
+				t.Error("Get() doesn't return the shrunk state")
+			}
+		})
+	}
+}
+
+func TestKubernetesClientChecksumMismatch(t *testing.T) {
+	c, rClient := newFakeKubernetesClient(t, `{"secret_suffix": "test", "compress": false, "chunk_size": 100}`)
+	if err := c.Put(randomState(250)); err != nil {
+		t.Fatal(err)
+	}
+
+	// a chunk written by a concurrent Put
+	part, err := c.getSecret(c.chunkName(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Data[tfstateKey] = randomState(100)
+	if err := rClient.Update(context.Background(), part); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.Get(); err == nil {
+		t.Error("Get() succeeded with a chunk not matching the checksum of the state")
+	}
+}
+
+func TestKubernetesClientLock(t *testing.T) {
+	c, rClient := newFakeKubernetesClient(t, `{"secret_suffix": "test"}`)
+	leaseKey := types.NamespacedName{Namespace: "default", Name: c.leaseName}
+
+	id, err := c.Lock(statemgr.NewLockInfo())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lease coordinationv1.Lease
+	if err := rClient.Get(context.Background(), leaseKey, &lease); err != nil {
+		t.Fatal(err)
+	}
+	if lease.Spec.LeaseDurationSeconds == nil || lease.Spec.AcquireTime == nil || lease.Spec.RenewTime == nil {
+		t.Errorf("lease %s has no duration", c.leaseName)
+	}
+
+	var lockErr *statemgr.LockError
+	if _, err := c.Lock(statemgr.NewLockInfo()); !errors.As(err, &lockErr) {
+		t.Errorf("Lock() of a locked state returned %v, want a LockError", err)
+	} else if lockErr.Info == nil || lockErr.Info.ID != id {
+		t.Errorf("LockError of a locked state doesn't hold the info of lock %s", id)
+	}
+
+	// the lock of a replica which crashed expires
+	expired := metav1.NewMicroTime(time.Now().Add(-2 * stateLockLeaseDuration))
+	lease.Spec.AcquireTime = &expired
+	lease.Spec.RenewTime = &expired
+	if err := rClient.Update(context.Background(), &lease); err != nil {
+		t.Fatal(err)
+	}
+	id2, err := c.Lock(statemgr.NewLockInfo())
+	if err != nil {
+		t.Fatalf("Lock() of an expired lock failed: %v", err)
+	}
+	if err := c.Unlock(id); err == nil {
+		t.Error("Unlock() succeeded with the id of an expired lock")
+	}
+	if err := c.Unlock(id2); err != nil {
+		t.Error(err)
+	}
+
+	// a lock of Terraform has no duration and is held until it is unlocked
+	if err := rClient.Get(context.Background(), leaseKey, &lease); err != nil {
+		t.Fatal(err)
+	}
+	terraformID := "terraform"
+	lease.Spec = coordinationv1.LeaseSpec{HolderIdentity: &terraformID}
+	if err := rClient.Update(context.Background(), &lease); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Lock(statemgr.NewLockInfo()); !errors.As(err, &lockErr) {
+		t.Errorf("Lock() of a state locked by Terraform returned %v, want a LockError", err)
+	}
+}
@@ -0,0 +1,147 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the AppsCode Community License 1.0.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://github.com/appscode/licenses/raw/1.0.0/AppsCode-Community-1.0.0.md
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/cli-utils/pkg/kstatus/status"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// results of a reconcile
+const (
+	ResultCreated   = "created"
+	ResultUpdated   = "updated"
+	ResultReplaced  = "replaced"
+	ResultDestroyed = "destroyed"
+	ResultOrphaned  = "orphaned"
+	ResultNoop      = "no-op"
+	ResultFailed    = "failed"
+)
+
+// requests sent to the provider
+const (
+	OperationCreate  = "create"
+	OperationUpdate  = "update"
+	OperationDestroy = "destroy"
+)
+
+var (
+	reconcileTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "kubeform_dynatrace",
+			Name:      "reconcile_total",
+			Help:      "Number of reconciles of the objects by result.",
+		},
+		[]string{"group", "version", "kind", "result"},
+	)
+
+	operationDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "kubeform_dynatrace",
+			Name:      "provider_operation_duration_seconds",
+			Help:      "Duration of the requests sent to the provider.",
+			Buckets:   []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+		},
+		[]string{"group", "version", "kind", "operation"},
+	)
+
+	driftTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "kubeform_dynatrace",
+			Name:      "drift_detected_total",
+			Help:      "Number of times an object was found to differ from the live object in Dynatrace.",
+		},
+		[]string{"group", "version", "kind"},
+	)
+
+	objectsByPhase = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "kubeform_dynatrace",
+			Name:      "objects",
+			Help:      "Number of objects by current phase.",
+		},
+		[]string{"group", "version", "kind", "phase"},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(reconcileTotal, operationDuration, driftTotal, objectsByPhase)
+}
+
+func recordReconcile(obj *unstructured.Unstructured, result string) {
+	gvk := obj.GroupVersionKind()
+	reconcileTotal.WithLabelValues(gvk.Group, gvk.Version, gvk.Kind, result).Inc()
+}
+
+// observeOperation records the duration of a request to the provider which started at start
+func observeOperation(obj *unstructured.Unstructured, operation string, start time.Time) {
+	gvk := obj.GroupVersionKind()
+	operationDuration.WithLabelValues(gvk.Group, gvk.Version, gvk.Kind, operation).Observe(time.Since(start).Seconds())
+}
+
+func recordDrift(obj *unstructured.Unstructured) {
+	gvk := obj.GroupVersionKind()
+	driftTotal.WithLabelValues(gvk.Group, gvk.Version, gvk.Kind).Inc()
+}
+
+type objectKey struct {
+	gvk schema.GroupVersionKind
+	key types.NamespacedName
+}
+
+// phases holds the last known phase of every object to keep objectsByPhase up to date
+var phases = struct {
+	sync.Mutex
+	mp map[objectKey]status.Status
+}{mp: make(map[objectKey]status.Status)}
+
+func setPhaseMetric(obj *unstructured.Unstructured, phase status.Status) {
+	gvk := obj.GroupVersionKind()
+	k := objectKey{gvk: gvk, key: types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()}}
+
+	phases.Lock()
+	defer phases.Unlock()
+
+	if old, ok := phases.mp[k]; ok {
+		if old == phase {
+			return
+		}
+		objectsByPhase.WithLabelValues(gvk.Group, gvk.Version, gvk.Kind, string(old)).Dec()
+	}
+	phases.mp[k] = phase
+	objectsByPhase.WithLabelValues(gvk.Group, gvk.Version, gvk.Kind, string(phase)).Inc()
+}
+
+// ForgetObjectMetrics removes the object from the metrics once it has been deleted
+func ForgetObjectMetrics(gvk schema.GroupVersionKind, key types.NamespacedName) {
+	k := objectKey{gvk: gvk, key: key}
+
+	phases.Lock()
+	defer phases.Unlock()
+
+	if old, ok := phases.mp[k]; ok {
+		objectsByPhase.WithLabelValues(gvk.Group, gvk.Version, gvk.Kind, string(old)).Dec()
+		delete(phases.mp, k)
+	}
+}
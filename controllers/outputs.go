@@ -0,0 +1,232 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the AppsCode Community License 1.0.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://github.com/appscode/licenses/raw/1.0.0/AppsCode-Community-1.0.0.md
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	tfschema "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	jsoniter "github.com/json-iterator/go"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// OutputsKey holds the computed attributes of the object in Dynatrace which are not sensitive, as a json
+	// object keyed by the fields of spec.resource. The status schema of the CRDs only has the conditions, phase
+	// and observedGeneration, so the outputs can't be kept in the status.
+	OutputsKey = "dynatrace.kubeform.com/outputs"
+	// ConnectionSecretKey names the Secret in the namespace of the object which the computed and the sensitive
+	// attributes of the object are written to, e.g. to be mounted by the workloads using the object
+	ConnectionSecretKey = "dynatrace.kubeform.com/write-connection-secret-to"
+)
+
+// publishOutputs publishes the computed attributes of the applied object in the outputs annotation and
+// writes them, along with the sensitive attributes, to the connection secret of the object
+func publishOutputs(rClient client.Client, ctx context.Context, gv schema.GroupVersion, res *tfschema.Resource, obj *unstructured.Unstructured, jsonit jsoniter.API) error {
+	state, err := getState(rClient, ctx, gv, obj, jsonit)
+	if err != nil {
+		return err
+	}
+	resType, err := getResourceType(gv, obj)
+	if err != nil {
+		return err
+	}
+
+	outputs := make(map[string]interface{})
+	connectionData := make(map[string][]byte)
+	for attr, sch := range res.Schema {
+		val := state[attr]
+		if val == nil || val == "" {
+			continue
+		}
+		field := strings.TrimPrefix(resourceFieldPath(resType, []string{attr}), "spec.resource.")
+		sensitive := sch.Sensitive || isSensitiveField(resType, attr)
+
+		if sch.Computed && !sensitive {
+			outputs[field] = val
+		}
+		if sch.Computed || sensitive {
+			data, err := outputValue(val)
+			if err != nil {
+				return err
+			}
+			connectionData[field] = data
+		}
+	}
+
+	err = setOutputsAnnotation(rClient, ctx, obj, outputs)
+	if err != nil {
+		return err
+	}
+
+	secretName := obj.GetAnnotations()[ConnectionSecretKey]
+	if secretName == "" {
+		return nil
+	}
+	return writeConnectionSecret(rClient, ctx, obj, secretName, connectionData)
+}
+
+// getState returns the last applied state of the object, keyed by the terraform attribute names
+func getState(rClient client.Client, ctx context.Context, gv schema.GroupVersion, obj *unstructured.Unstructured, jsonit jsoniter.API) (map[string]interface{}, error) {
+	backendRef, backendFound, err := unstructured.NestedString(obj.Object, "spec", "backendRef", "name")
+	if err != nil {
+		return nil, err
+	}
+	if !backendFound {
+		return getStatusWithSensitiveData(gv, rClient, ctx, obj, jsonit)
+	}
+
+	remoteClient, err := getRemoteClient(backendRef, rClient, ctx, obj, jsonit)
+	if err != nil {
+		return nil, err
+	}
+	payloadData, err := getRemoteState(remoteClient)
+	if err != nil {
+		return nil, err
+	}
+
+	state := make(map[string]interface{})
+	if payloadData == nil {
+		return state, nil
+	}
+	payLoad := &stateV4{}
+	err = json.Unmarshal(payloadData, payLoad)
+	if err != nil {
+		return nil, err
+	}
+	if len(payLoad.Resources) > 0 && len(payLoad.Resources[0].Instances) > 0 {
+		err = json.Unmarshal(payLoad.Resources[0].Instances[0].AttributesRaw, &state)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return state, nil
+}
+
+// isSensitiveField returns true if the field of the resource for the terraform attribute is stored in the sensitive secret
+func isSensitiveField(resType reflect.Type, attr string) bool {
+	for resType.Kind() == reflect.Ptr {
+		resType = resType.Elem()
+	}
+	if resType.Kind() != reflect.Struct {
+		return false
+	}
+	for i := 0; i < resType.NumField(); i++ {
+		field := resType.Field(i)
+		if strings.Split(field.Tag.Get("tf"), ",")[0] == attr {
+			return field.Tag.Get("sensitive") == "true"
+		}
+	}
+	return false
+}
+
+// outputValue returns the value of the attribute in the connection secret: strings as they are, other values as json
+func outputValue(val interface{}) ([]byte, error) {
+	if s, ok := val.(string); ok {
+		return []byte(s), nil
+	}
+	return json.Marshal(val)
+}
+
+func setOutputsAnnotation(rClient client.Client, ctx context.Context, obj *unstructured.Unstructured, outputs map[string]interface{}) error {
+	annotations := obj.GetAnnotations()
+	val := ""
+	if len(outputs) > 0 {
+		data, err := json.Marshal(outputs)
+		if err != nil {
+			return err
+		}
+		val = string(data)
+	}
+	if annotations[OutputsKey] == val {
+		return nil
+	}
+
+	// only the metadata is patched, so that concurrent changes of the spec do not conflict with the outputs
+	latest := obj.DeepCopy()
+	patch := client.MergeFrom(obj.DeepCopy())
+	if val == "" {
+		delete(annotations, OutputsKey)
+	} else {
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		annotations[OutputsKey] = val
+	}
+	latest.SetAnnotations(annotations)
+
+	err := rClient.Patch(ctx, latest, patch)
+	if err != nil {
+		return err
+	}
+	obj.SetAnnotations(latest.GetAnnotations())
+	obj.SetResourceVersion(latest.GetResourceVersion())
+	return nil
+}
+
+// writeConnectionSecret creates or updates the connection secret of the object. The secret is owned by the
+// object, so that it is garbage collected along with it.
+func writeConnectionSecret(rClient client.Client, ctx context.Context, obj *unstructured.Unstructured, secretName string, data map[string][]byte) error {
+	var secret corev1.Secret
+	req := types.NamespacedName{
+		Namespace: obj.GetNamespace(),
+		Name:      secretName,
+	}
+	err := rClient.Get(ctx, req, &secret)
+	if errors.IsNotFound(err) {
+		tr := true
+		return rClient.Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      secretName,
+				Namespace: obj.GetNamespace(),
+				OwnerReferences: []metav1.OwnerReference{
+					{
+						APIVersion: obj.GetAPIVersion(),
+						Kind:       obj.GetKind(),
+						Name:       obj.GetName(),
+						Controller: &tr,
+						UID:        obj.GetUID(),
+					},
+				},
+			},
+			Type: corev1.SecretTypeOpaque,
+			Data: data,
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	if owner := metav1.GetControllerOf(&secret); owner == nil || owner.UID != obj.GetUID() {
+		return fmt.Errorf("secret %s/%s of %s is not owned by %s", secret.Namespace, secret.Name, ConnectionSecretKey, obj.GetName())
+	}
+	if (len(secret.Data) == 0 && len(data) == 0) || reflect.DeepEqual(secret.Data, data) {
+		return nil
+	}
+	secret.Data = data
+	return rClient.Update(ctx, &secret)
+}
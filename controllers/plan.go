@@ -0,0 +1,380 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the AppsCode Community License 1.0.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://github.com/appscode/licenses/raw/1.0.0/AppsCode-Community-1.0.0.md
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/go-cty/cty/msgpack"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	tfschema "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	jsoniter "github.com/json-iterator/go"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
+	kmapi "kmodules.xyz/client-go/api/v1"
+	"sigs.k8s.io/cli-utils/pkg/kstatus/status"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// DryRunKey set to "true" makes the controller publish the plan of the object in the plan annotation
+	// instead of applying it in Dynatrace
+	DryRunKey = "dynatrace.kubeform.com/dry-run"
+	// PlanKey holds the plan of an object in dry-run mode, as json
+	PlanKey = "dynatrace.kubeform.com/plan"
+
+	ConditionDryRun = "DryRun"
+
+	PlanActionCreate  = "create"
+	PlanActionUpdate  = "update"
+	PlanActionReplace = "replace"
+	PlanActionNoop    = "no-op"
+
+	// values of the plan which are only known once the object is applied
+	unknownPlanValue = "(known after apply)"
+	// values of the plan of the sensitive attributes
+	sensitivePlanValue = "(sensitive value)"
+
+	// unknownVariableValue is the value of the unknown attributes in a shimmed terraform configuration
+	unknownVariableValue = "74D93920-ED26-11E3-AC10-0800200C9A66"
+)
+
+// PlannedChange is the change of a field of spec.resource planned by the provider
+type PlannedChange struct {
+	Field           string `json:"field"`
+	Old             string `json:"old,omitempty"`
+	New             string `json:"new,omitempty"`
+	Sensitive       bool   `json:"sensitive,omitempty"`
+	RequiresReplace bool   `json:"requiresReplace,omitempty"`
+}
+
+// Plan is the outcome of applying an object in Dynatrace, as planned by the provider without changing anything
+type Plan struct {
+	Action          string                 `json:"action"`
+	RequiresReplace bool                   `json:"requiresReplace"`
+	Changes         []PlannedChange        `json:"changes,omitempty"`
+	PlannedState    map[string]interface{} `json:"plannedState,omitempty"`
+}
+
+// Summary returns a one line description of the plan
+func (p *Plan) Summary() string {
+	switch p.Action {
+	case PlanActionNoop:
+		return "No changes, the object is up to date in Dynatrace"
+	case PlanActionCreate:
+		return "The object will be created in Dynatrace"
+	case PlanActionReplace:
+		return fmt.Sprintf("%d field(s) will change, the object will be replaced in Dynatrace", len(p.Changes))
+	default:
+		return fmt.Sprintf("%d field(s) will change, the object will be updated in place in Dynatrace", len(p.Changes))
+	}
+}
+
+// PlanObject returns the changes which applying obj would make in Dynatrace. The last applied state is read
+// from current, the object of the same name in the cluster, which is nil if it doesn't exist yet.
+func PlanObject(rClient client.Client, provider *tfschema.Provider, ctx context.Context, res *tfschema.Resource, gv schema.GroupVersion, obj *unstructured.Unstructured, current *unstructured.Unstructured, tName string, jsonit jsoniter.API) (*Plan, error) {
+	rawSpec, err := getSpecWithSensitiveData(gv, rClient, ctx, obj, jsonit)
+	if err != nil {
+		return nil, err
+	}
+	rawStatus := make(map[string]interface{})
+	if current != nil {
+		rawStatus, err = getState(rClient, ctx, gv, current, jsonit)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	server, _, err := getProviderServer(rClient, provider, ctx, obj)
+	if err != nil {
+		return nil, err
+	}
+	resType, err := getResourceType(gv, obj)
+	if err != nil {
+		return nil, err
+	}
+
+	if rawStatus["id"] == nil && rawSpec["id"] != nil {
+		// the existing object is adopted, plan the changes against the live object
+		importedState, exists, err := importTheObject(fmt.Sprint(rawSpec["id"]), res, server, tName)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, fmt.Errorf("%s with id %v doesn't exist in Dynatrace, remove spec.resource.id to create a new one", tName, rawSpec["id"])
+		}
+		rawStatus = importedState
+	}
+
+	plan := &Plan{}
+	var plannedVal cty.Value
+	var requiresReplace [][]string
+	if rawStatus["id"] == nil {
+		plan.Action = PlanActionCreate
+		rawStatus = make(map[string]interface{})
+		rawSpec["id"] = UnknownIdValue
+		plannedVal, err = planCreate(rawSpec, res, server, tName)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		combineRaw, err := getCombineRawAndDeepCopyRawStatus(rawStatus, rawSpec)
+		if err != nil {
+			return nil, err
+		}
+		changed, err := hasResourceChanged(combineRaw, rawStatus, res)
+		if err != nil {
+			return nil, err
+		}
+		if !changed {
+			plan.Action = PlanActionNoop
+			plan.PlannedState = planValues(rawStatus, res)
+			return plan, nil
+		}
+
+		requireNew, _, planResp, _, err := checkRequireNewOrNot(combineRaw, rawStatus, res, server, tName)
+		if err != nil {
+			return nil, err
+		}
+		plan.Action = PlanActionUpdate
+		if requireNew {
+			plan.Action = PlanActionReplace
+			plan.RequiresReplace = true
+		}
+		for _, path := range planResp.RequiresReplace {
+			requiresReplace = append(requiresReplace, attributePathSteps(path))
+		}
+		plannedVal, err = msgpack.Unmarshal(planResp.PlannedState.MsgPack, res.CoreConfigSchema().ImpliedType())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	plannedState := terraform.NewResourceConfigShimmed(plannedVal, res.CoreConfigSchema()).Raw
+	drift, err := getDrift(rawStatus, plannedState, res)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range drift {
+		steps := strings.Split(d.Path, ".")
+		change := PlannedChange{
+			Field:           resourceFieldPath(resType, steps),
+			Sensitive:       d.Sensitive,
+			RequiresReplace: hasPathPrefix(steps, requiresReplace),
+		}
+		if !d.Sensitive {
+			change.Old = planValue(d.Old)
+			change.New = planValue(d.New)
+		}
+		plan.Changes = append(plan.Changes, change)
+	}
+	plan.PlannedState = planValues(plannedState, res)
+	return plan, nil
+}
+
+// planCreate returns the state of the object planned by the provider when it is created
+func planCreate(rawSpec map[string]interface{}, res *tfschema.Resource, server *tfschema.GRPCProviderServer, tName string) (cty.Value, error) {
+	schma := res.CoreConfigSchema()
+	priorState, err := msgpack.Marshal(cty.NullVal(schma.ImpliedType()), schma.ImpliedType())
+	if err != nil {
+		return cty.NilVal, err
+	}
+	plannedState, err := msgpack.Marshal(HCL2ValueFromConfigValue(rawSpec), schma.ImpliedType())
+	if err != nil {
+		return cty.NilVal, err
+	}
+
+	planResp, err := server.PlanResourceChange(context.Background(), &tfprotov5.PlanResourceChangeRequest{
+		TypeName: tName,
+		PriorState: &tfprotov5.DynamicValue{
+			MsgPack: priorState,
+		},
+		ProposedNewState: &tfprotov5.DynamicValue{
+			MsgPack: plannedState,
+		},
+		Config: &tfprotov5.DynamicValue{
+			MsgPack: plannedState,
+		},
+	})
+	if err != nil {
+		return cty.NilVal, err
+	}
+	if len(planResp.Diagnostics) > 0 {
+		return cty.NilVal, diagToError(planResp.Diagnostics)
+	}
+	return msgpack.Unmarshal(planResp.PlannedState.MsgPack, schma.ImpliedType())
+}
+
+// hasPathPrefix returns true if the attribute path is one of the paths, or nested in one of them
+func hasPathPrefix(steps []string, paths [][]string) bool {
+	for _, path := range paths {
+		if len(path) > len(steps) {
+			continue
+		}
+		matches := true
+		for i := range path {
+			if path[i] != steps[i] && path[i] != "*" {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
+
+func planValue(val string) string {
+	if val == unknownVariableValue || val == UnknownIdValue {
+		return unknownPlanValue
+	}
+	return val
+}
+
+// planValues returns the state without the values of the sensitive attributes and with the unknown values marked
+func planValues(state map[string]interface{}, res *tfschema.Resource) map[string]interface{} {
+	out := make(map[string]interface{}, len(state))
+	for attr, val := range state {
+		if sch, ok := res.Schema[attr]; ok && sch.Sensitive && val != nil {
+			out[attr] = sensitivePlanValue
+			continue
+		}
+		out[attr] = planUnknownValues(val)
+	}
+	return out
+}
+
+func planUnknownValues(val interface{}) interface{} {
+	switch v := val.(type) {
+	case string:
+		return planValue(v)
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i := range v {
+			out[i] = planUnknownValues(v[i])
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k := range v {
+			out[k] = planUnknownValues(v[k])
+		}
+		return out
+	default:
+		return val
+	}
+}
+
+// isDryRun returns true if the object is only planned, not applied
+func isDryRun(obj *unstructured.Unstructured) bool {
+	return obj.GetAnnotations()[DryRunKey] == "true"
+}
+
+// DryRunChanged returns true if the dry-run annotation of the object was set or removed. The change only
+// touches the metadata, so the update predicate of the controllers has to let it through explicitly.
+func DryRunChanged(oldObj, newObj client.Object) bool {
+	return oldObj.GetAnnotations()[DryRunKey] != newObj.GetAnnotations()[DryRunKey]
+}
+
+// setPlanAnnotation writes the plan into the plan annotation of the object
+func setPlanAnnotation(rClient client.Client, ctx context.Context, obj *unstructured.Unstructured, val string) error {
+	annotations := obj.GetAnnotations()
+	if annotations[PlanKey] == val {
+		return nil
+	}
+
+	// only the metadata is patched, so that concurrent changes of the spec do not conflict with the plan
+	latest := obj.DeepCopy()
+	patch := client.MergeFrom(obj.DeepCopy())
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[PlanKey] = val
+	latest.SetAnnotations(annotations)
+
+	err := rClient.Patch(ctx, latest, patch)
+	if err != nil {
+		return err
+	}
+	obj.SetAnnotations(latest.GetAnnotations())
+	obj.SetResourceVersion(latest.GetResourceVersion())
+	return nil
+}
+
+// dryRun publishes the plan of the object in the plan annotation and the DryRun condition
+func dryRun(rClient client.Client, recorder record.EventRecorder, provider *tfschema.Provider, ctx context.Context, res *tfschema.Resource, gv schema.GroupVersion, obj *unstructured.Unstructured, tName string, jsonit jsoniter.API, resyncPeriod time.Duration) (ctrl.Result, error) {
+	plan, err := PlanObject(rClient, provider, ctx, res, gv, obj, obj, tName, jsonit)
+	if err != nil {
+		recorder.Event(obj, corev1.EventTypeWarning, EventReasonFailed, err.Error())
+		err2 := initialUpdateStatus(rClient, ctx, gv, obj, err, false)
+		if err2 != nil {
+			return ctrl.Result{}, err2
+		}
+		return ctrl.Result{}, err
+	}
+
+	data, err := json.Marshal(plan)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if err := setPlanAnnotation(rClient, ctx, obj, string(data)); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	defaults, err := getProviderDefaults(rClient, ctx, obj)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	conditions, err := getConditions(gv, obj)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	// the Drifted and APIToken conditions are kept, as after applying the object
+	newCondi := []kmapi.Condition{kmapi.NewCondition(ConditionDryRun, plan.Summary(), obj.GetGeneration())}
+	if getDriftPolicy(obj, defaults) == DriftPolicyReport {
+		if _, cond := kmapi.GetCondition(conditions, ConditionDrifted); cond != nil {
+			newCondi = append(newCondi, *cond)
+		}
+	}
+	if _, cond := kmapi.GetCondition(conditions, ConditionAPIToken); cond != nil {
+		newCondi = append(newCondi, *cond)
+	}
+	err = setNestedFieldNoCopy(obj.Object, newCondi, "status", "conditions")
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if err = rClient.Status().Update(ctx, obj); err != nil {
+		return ctrl.Result{}, err
+	}
+	err = updateStatus(rClient, ctx, obj, status.CurrentStatus)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// plan the object again later, the plan follows the changes made in Dynatrace
+	return ctrl.Result{RequeueAfter: resyncPeriod}, nil
+}
@@ -0,0 +1,246 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the AppsCode Community License 1.0.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://github.com/appscode/licenses/raw/1.0.0/AppsCode-Community-1.0.0.md
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	dynatrace "github.com/dynatrace-oss/terraform-provider-dynatrace/provider"
+	tfschema "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	kmapi "kmodules.xyz/client-go/api/v1"
+	managementv1alpha1 "kubeform.dev/provider-dynatrace-api/apis/management/v1alpha1"
+	dynatracescheme "kubeform.dev/provider-dynatrace-api/client/clientset/versioned/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestHasPathPrefix(t *testing.T) {
+	paths := [][]string{{"name"}, {"rules", "*", "type"}}
+	tests := []struct {
+		steps []string
+		want  bool
+	}{
+		{steps: []string{"name"}, want: true},
+		{steps: []string{"rules", "0", "type"}, want: true},
+		{steps: []string{"rules", "1", "type", "value"}, want: true},
+		{steps: []string{"rules", "0", "enabled"}},
+		{steps: []string{"rules"}},
+		{steps: []string{"description"}},
+	}
+	for _, tt := range tests {
+		if got := hasPathPrefix(tt.steps, paths); got != tt.want {
+			t.Errorf("hasPathPrefix(%v) = %v, want %v", tt.steps, got, tt.want)
+		}
+	}
+	if hasPathPrefix([]string{"name"}, nil) {
+		t.Error("hasPathPrefix() without paths = true, want false")
+	}
+}
+
+func TestPlanValues(t *testing.T) {
+	res := &tfschema.Resource{Schema: map[string]*tfschema.Schema{
+		"name":     {Type: tfschema.TypeString},
+		"password": {Type: tfschema.TypeString, Sensitive: true},
+		"token":    {Type: tfschema.TypeString, Sensitive: true},
+		"rules":    {Type: tfschema.TypeList},
+	}}
+	state := map[string]interface{}{
+		"id":       UnknownIdValue,
+		"name":     "shop",
+		"password": "secret",
+		"token":    nil,
+		"rules": []interface{}{
+			map[string]interface{}{"type": "SERVICE", "value": unknownVariableValue, "enabled": true},
+		},
+	}
+	want := map[string]interface{}{
+		"id":       unknownPlanValue,
+		"name":     "shop",
+		"password": sensitivePlanValue,
+		"token":    nil,
+		"rules": []interface{}{
+			map[string]interface{}{"type": "SERVICE", "value": unknownPlanValue, "enabled": true},
+		},
+	}
+	if got := planValues(state, res); !reflect.DeepEqual(got, want) {
+		t.Errorf("planValues() = %v, want %v", got, want)
+	}
+}
+
+func newZone(name, description string) *unstructured.Unstructured {
+	zone := &managementv1alpha1.Zone{
+		TypeMeta:   metav1.TypeMeta{APIVersion: managementv1alpha1.SchemeGroupVersion.String(), Kind: "Zone"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "zone"},
+		Spec: managementv1alpha1.ZoneSpec{
+			Resource:    managementv1alpha1.ZoneSpecResource{Name: &name, Description: &description},
+			ProviderRef: corev1.LocalObjectReference{Name: "dynatrace"},
+		},
+	}
+	content, _ := runtime.DefaultUnstructuredConverter.ToUnstructured(zone)
+	return &unstructured.Unstructured{Object: content}
+}
+
+func newFakePlanClient(t *testing.T, objs ...client.Object) client.Client {
+	if err := dynatracescheme.AddToScheme(clientgoscheme.Scheme); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		providerServers.Lock()
+		providerServers.mp = make(map[string]*pooledServer)
+		providerServers.Unlock()
+	})
+	objs = append(objs, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "dynatrace"},
+		Data: map[string][]byte{
+			"provider": []byte(`{"dt_env_url": "https://abc12345.live.dynatrace.com", "dt_api_token": "dt0c01.token"}`),
+		},
+	})
+	return fake.NewClientBuilder().WithObjects(objs...).Build()
+}
+
+func TestPlanObject(t *testing.T) {
+	rClient := newFakePlanClient(t)
+	provider := dynatrace.Provider()
+	tName := "dynatrace_management_zone"
+	res := provider.ResourcesMap[tName]
+	gv := managementv1alpha1.SchemeGroupVersion
+	jsonit := GetJSONItr(managementv1alpha1.GetEncoder(), managementv1alpha1.GetDecoder())
+
+	// a new object is created, its id is only known once it is applied
+	obj := newZone("shop", "all services of the shop")
+	plan, err := PlanObject(rClient, provider, context.Background(), res, gv, obj, nil, tName, jsonit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Action != PlanActionCreate || plan.RequiresReplace {
+		t.Errorf("plan of a new object = %s, requires replace %v, want %s", plan.Action, plan.RequiresReplace, PlanActionCreate)
+	}
+	if plan.PlannedState["id"] != unknownPlanValue || plan.PlannedState["name"] != "shop" {
+		t.Errorf("planned state of a new object = %v", plan.PlannedState)
+	}
+
+	// the last applied state of the object is read from spec.state of the object in the cluster
+	current := newZone("shop", "all services of the shop")
+	state, _, _ := unstructured.NestedMap(current.Object, "spec", "resource")
+	state["id"] = "-3217543280474271536"
+	_ = unstructured.SetNestedMap(current.Object, state, "spec", "state")
+
+	plan, err = PlanObject(rClient, provider, context.Background(), res, gv, current, current, tName, jsonit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Action != PlanActionNoop || len(plan.Changes) > 0 {
+		t.Errorf("plan of an unchanged object = %s with changes %v, want %s", plan.Action, plan.Changes, PlanActionNoop)
+	}
+
+	obj = newZone("shop", "the services of the shop")
+	plan, err = PlanObject(rClient, provider, context.Background(), res, gv, obj, current, tName, jsonit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []PlannedChange{{Field: "spec.resource.description", Old: "all services of the shop", New: "the services of the shop"}}
+	if plan.Action != PlanActionUpdate || !reflect.DeepEqual(plan.Changes, want) {
+		t.Errorf("plan of a changed object = %s with changes %+v, want %s with %+v", plan.Action, plan.Changes, PlanActionUpdate, want)
+	}
+}
+
+// jsonStatusClient sends the status of the objects as json, like the client of the API server, so that the
+// status may hold typed values such as the conditions
+type jsonStatusClient struct {
+	client.Client
+}
+
+func (c jsonStatusClient) Status() client.StatusWriter {
+	return jsonStatusWriter{c.Client.Status()}
+}
+
+type jsonStatusWriter struct {
+	client.StatusWriter
+}
+
+func (w jsonStatusWriter) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	u := obj.(*unstructured.Unstructured)
+	data, err := json.Marshal(u.Object)
+	if err != nil {
+		return err
+	}
+	sent := &unstructured.Unstructured{}
+	if err := json.Unmarshal(data, &sent.Object); err != nil {
+		return err
+	}
+	if err := w.StatusWriter.Update(ctx, sent, opts...); err != nil {
+		return err
+	}
+	u.SetResourceVersion(sent.GetResourceVersion())
+	return nil
+}
+
+func TestDryRun(t *testing.T) {
+	obj := newZone("shop", "all services of the shop")
+	obj.SetAnnotations(map[string]string{DryRunKey: "true", DriftPolicyKey: DriftPolicyReport})
+	obj.SetGeneration(1)
+	conditions, _ := runtime.DefaultUnstructuredConverter.ToUnstructured(&managementv1alpha1.ZoneStatus{
+		Conditions: []kmapi.Condition{
+			kmapi.NewCondition(ConditionAPIToken, "The primary API token is used", 1, true),
+			kmapi.NewCondition(ConditionDrifted, "description changed in Dynatrace", 1, true),
+		},
+	})
+	_ = unstructured.SetNestedField(obj.Object, conditions["conditions"], "status", "conditions")
+	rClient := jsonStatusClient{newFakePlanClient(t, obj)}
+
+	if err := rClient.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "zone"}, obj); err != nil {
+		t.Fatal(err)
+	}
+	provider := dynatrace.Provider()
+	tName := "dynatrace_management_zone"
+	jsonit := GetJSONItr(managementv1alpha1.GetEncoder(), managementv1alpha1.GetDecoder())
+	_, err := dryRun(rClient, record.NewFakeRecorder(10), provider, context.Background(), provider.ResourcesMap[tName], managementv1alpha1.SchemeGroupVersion, obj, tName, jsonit, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var latest unstructured.Unstructured
+	latest.SetGroupVersionKind(obj.GroupVersionKind())
+	if err := rClient.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "zone"}, &latest); err != nil {
+		t.Fatal(err)
+	}
+	if latest.GetAnnotations()[PlanKey] == "" {
+		t.Error("plan annotation is not written")
+	}
+	if latest.GetAnnotations()[DryRunKey] != "true" {
+		t.Error("dry-run annotation is lost")
+	}
+	got, err := getConditions(managementv1alpha1.SchemeGroupVersion, &latest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, condType := range []string{ConditionDryRun, ConditionAPIToken, ConditionDrifted} {
+		if !kmapi.HasCondition(got, condType) {
+			t.Errorf("condition %s is missing from %v", condType, got)
+		}
+	}
+}
@@ -0,0 +1,270 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the AppsCode Community License 1.0.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://github.com/appscode/licenses/raw/1.0.0/AppsCode-Community-1.0.0.md
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	errors2 "errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	base "kubeform.dev/apimachinery/api/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	ProviderConfigGroup       = "provider.dynatrace.kubeform.com"
+	ProviderConfigVersion     = "v1alpha1"
+	ProviderConfigKind        = "ProviderConfig"
+	ClusterProviderConfigKind = "ClusterProviderConfig"
+
+	// ProviderConfigKey names the ProviderConfig, or the ClusterProviderConfig if there is no ProviderConfig
+	// of that name in the namespace of the object, used instead of the secret of spec.providerRef
+	ProviderConfigKey = "dynatrace.kubeform.com/provider-config"
+
+	defaultAPITokenKey = "apiToken"
+
+	environmentCheckTimeout = 5 * time.Second
+)
+
+// ProviderConfigSpec is the spec of a ProviderConfig or a ClusterProviderConfig
+type ProviderConfigSpec struct {
+	// URL of the Dynatrace environment, e.g. https://abc12345.live.dynatrace.com
+	EnvURL string `json:"envURL"`
+	// Secret holding the API token of the environment
+	APITokenSecretRef SecretKeySelector `json:"apiTokenSecretRef"`
+	// Secret holding the API token used when the environment rejects the API token, e.g. while the tokens are rotated
+	SecondaryAPITokenSecretRef *SecretKeySelector `json:"secondaryAPITokenSecretRef,omitempty"`
+	// URL of the Dynatrace cluster, required by the cluster management resources
+	ClusterURL string `json:"clusterURL,omitempty"`
+	// Secret holding the API token of the Dynatrace cluster
+	ClusterAPITokenSecretRef *SecretKeySelector `json:"clusterAPITokenSecretRef,omitempty"`
+	// Defaults of the objects using the configuration
+	Defaults *ProviderDefaults `json:"defaults,omitempty"`
+}
+
+// SecretKeySelector selects a key of a Secret. The namespace is only used by a ClusterProviderConfig,
+// a ProviderConfig always reads the Secrets of its own namespace.
+type SecretKeySelector struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	Key       string `json:"key,omitempty"`
+}
+
+// ProviderDefaults are used by the objects which don't set the corresponding annotation or field
+type ProviderDefaults struct {
+	DriftPolicy       string                 `json:"driftPolicy,omitempty"`
+	TerminationPolicy base.TerminationPolicy `json:"terminationPolicy,omitempty"`
+}
+
+// GetProviderConfig returns the ProviderConfig of the given name in the namespace, or the
+// ClusterProviderConfig of that name if the namespace has no such ProviderConfig
+func GetProviderConfig(rClient client.Client, ctx context.Context, namespace, name string) (*unstructured.Unstructured, *ProviderConfigSpec, error) {
+	pc := &unstructured.Unstructured{}
+	pc.SetGroupVersionKind(schema.GroupVersionKind{Group: ProviderConfigGroup, Version: ProviderConfigVersion, Kind: ProviderConfigKind})
+	err := rClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, pc)
+	if errors.IsNotFound(err) {
+		pc = &unstructured.Unstructured{}
+		pc.SetGroupVersionKind(schema.GroupVersionKind{Group: ProviderConfigGroup, Version: ProviderConfigVersion, Kind: ClusterProviderConfigKind})
+		err = rClient.Get(ctx, types.NamespacedName{Name: name}, pc)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	spec, err := GetProviderConfigSpec(pc)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pc, spec, nil
+}
+
+// GetProviderConfigSpec returns the spec of the ProviderConfig or ClusterProviderConfig
+func GetProviderConfigSpec(pc *unstructured.Unstructured) (*ProviderConfigSpec, error) {
+	content, _, err := unstructured.NestedMap(pc.Object, "spec")
+	if err != nil {
+		return nil, err
+	}
+	spec := &ProviderConfigSpec{}
+	err = runtime.DefaultUnstructuredConverter.FromUnstructured(content, spec)
+	if err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+// GetProviderConfigData returns the configuration of the provider, keyed by the terraform attribute names,
+// for the ProviderConfig or ClusterProviderConfig
+func GetProviderConfigData(rClient client.Client, ctx context.Context, pc *unstructured.Unstructured, spec *ProviderConfigSpec) (map[string]interface{}, error) {
+	token, err := getSecretKey(rClient, ctx, pc, spec.APITokenSecretRef)
+	if err != nil {
+		return nil, err
+	}
+
+	mapData := map[string]interface{}{
+		"dt_env_url":           strings.TrimSuffix(spec.EnvURL, "/"),
+		"dt_api_token":         token,
+		"dt_cluster_url":       nil,
+		"dt_cluster_api_token": nil,
+	}
+	if spec.ClusterURL != "" {
+		mapData["dt_cluster_url"] = strings.TrimSuffix(spec.ClusterURL, "/")
+	}
+	if spec.ClusterAPITokenSecretRef != nil {
+		clusterToken, err := getSecretKey(rClient, ctx, pc, *spec.ClusterAPITokenSecretRef)
+		if err != nil {
+			return nil, err
+		}
+		mapData["dt_cluster_api_token"] = clusterToken
+	}
+	return mapData, nil
+}
+
+// GetSecondaryAPIToken returns the secondary API token of the ProviderConfig or ClusterProviderConfig, if any
+func GetSecondaryAPIToken(rClient client.Client, ctx context.Context, pc *unstructured.Unstructured, spec *ProviderConfigSpec) (string, error) {
+	if spec.SecondaryAPITokenSecretRef == nil {
+		return "", nil
+	}
+	return getSecretKey(rClient, ctx, pc, *spec.SecondaryAPITokenSecretRef)
+}
+
+// GetProviderConfigSecrets returns the Secrets referenced by the ProviderConfig or ClusterProviderConfig
+func GetProviderConfigSecrets(pc *unstructured.Unstructured, spec *ProviderConfigSpec) []types.NamespacedName {
+	refs := []SecretKeySelector{spec.APITokenSecretRef}
+	if spec.SecondaryAPITokenSecretRef != nil {
+		refs = append(refs, *spec.SecondaryAPITokenSecretRef)
+	}
+	if spec.ClusterAPITokenSecretRef != nil {
+		refs = append(refs, *spec.ClusterAPITokenSecretRef)
+	}
+
+	secrets := make([]types.NamespacedName, 0, len(refs))
+	for _, ref := range refs {
+		namespace := pc.GetNamespace()
+		if namespace == "" {
+			namespace = ref.Namespace
+		}
+		secrets = append(secrets, types.NamespacedName{Namespace: namespace, Name: ref.Name})
+	}
+	return secrets
+}
+
+func getSecretKey(rClient client.Client, ctx context.Context, pc *unstructured.Unstructured, ref SecretKeySelector) (string, error) {
+	namespace := pc.GetNamespace()
+	if namespace == "" {
+		namespace = ref.Namespace
+	}
+	key := ref.Key
+	if key == "" {
+		key = defaultAPITokenKey
+	}
+
+	var secret corev1.Secret
+	req := types.NamespacedName{
+		Namespace: namespace,
+		Name:      ref.Name,
+	}
+	if err := rClient.Get(ctx, req, &secret); err != nil {
+		return "", err
+	}
+	val, ok := secret.Data[key]
+	if !ok {
+		return "", fmt.Errorf("key %s not found in secret %s/%s", key, namespace, ref.Name)
+	}
+	return strings.TrimSpace(string(val)), nil
+}
+
+// environmentError is the response of the Dynatrace environment to a failed check of the API token
+type environmentError struct {
+	statusCode int
+	msg        string
+}
+
+func (e *environmentError) Error() string {
+	return e.msg
+}
+
+// IsTokenRejected returns true if the environment answered the check of the API token, and rejected it
+func IsTokenRejected(err error) bool {
+	var envErr *environmentError
+	if !errors2.As(err, &envErr) {
+		return false
+	}
+	return envErr.statusCode == http.StatusUnauthorized || envErr.statusCode == http.StatusForbidden
+}
+
+// CheckEnvironment verifies that the Dynatrace environment is reachable and accepts the API token
+func CheckEnvironment(ctx context.Context, envURL, token string) error {
+	u, err := url.Parse(envURL)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "https" && u.Scheme != "http" || u.Host == "" {
+		return fmt.Errorf("invalid environment url %q", envURL)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, environmentCheckTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(envURL, "/")+"/api/config/v1/clusterversion", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Api-Token "+token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return &environmentError{
+			statusCode: resp.StatusCode,
+			msg:        fmt.Sprintf("%s (GET) %s: %s", http.StatusText(resp.StatusCode), req.URL, strings.TrimSpace(string(body))),
+		}
+	}
+	return nil
+}
+
+// getProviderDefaults returns the defaults of the provider configuration used by the object
+func getProviderDefaults(rClient client.Client, ctx context.Context, obj *unstructured.Unstructured) (*ProviderDefaults, error) {
+	name, ok := obj.GetAnnotations()[ProviderConfigKey]
+	if !ok {
+		return &ProviderDefaults{}, nil
+	}
+	_, spec, err := GetProviderConfig(rClient, ctx, obj.GetNamespace(), name)
+	if err != nil {
+		return nil, err
+	}
+	if spec.Defaults == nil {
+		return &ProviderDefaults{}, nil
+	}
+	return spec.Defaults, nil
+}
@@ -0,0 +1,205 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the AppsCode Community License 1.0.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://github.com/appscode/licenses/raw/1.0.0/AppsCode-Community-1.0.0.md
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package providerconfig
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+	kmapi "kmodules.xyz/client-go/api/v1"
+	"kubeform.dev/provider-dynatrace-controller/controllers"
+	"sigs.k8s.io/cli-utils/pkg/kstatus/status"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	ConditionReady = "Ready"
+
+	// reachableCheckInterval is the interval at which the environments are checked when no resync period is set
+	reachableCheckInterval = 10 * time.Minute
+)
+
+// ProviderConfigReconciler publishes whether the Dynatrace environment of a ProviderConfig or
+// ClusterProviderConfig is reachable with the configured API token
+type ProviderConfigReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+
+	Gvk schema.GroupVersionKind // GVK of the ProviderConfig or ClusterProviderConfig
+
+	ResyncPeriod time.Duration // interval after which the environment is checked again
+}
+
+// +kubebuilder:rbac:groups=provider.dynatrace.kubeform.com,resources=*,verbs=get;list;watch
+// +kubebuilder:rbac:groups=provider.dynatrace.kubeform.com,resources=*/status,verbs=get;update;patch
+
+func (r *ProviderConfigReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues(r.Gvk.Kind, req.NamespacedName)
+
+	var unstructuredObj unstructured.Unstructured
+	unstructuredObj.SetGroupVersionKind(r.Gvk)
+
+	if err := r.Get(ctx, req.NamespacedName, &unstructuredObj); err != nil {
+		log.Error(err, "unable to fetch "+r.Gvk.Kind)
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	token, checkErr := r.checkEnvironment(ctx, &unstructuredObj)
+	if checkErr != nil {
+		log.Info("environment is not reachable", "reason", checkErr.Error())
+		r.Recorder.Event(&unstructuredObj, corev1.EventTypeWarning, controllers.EventReasonFailed, checkErr.Error())
+	} else if token == controllers.APITokenSecondary {
+		r.Recorder.Event(&unstructuredObj, corev1.EventTypeWarning, controllers.EventReasonSecondaryAPIToken, "The primary API token was rejected, the secondary API token is used")
+	}
+	if err := r.updateStatus(ctx, &unstructuredObj, token, checkErr); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	interval := r.ResyncPeriod
+	if interval <= 0 {
+		interval = reachableCheckInterval
+	}
+	return ctrl.Result{RequeueAfter: interval}, nil
+}
+
+// checkEnvironment returns which API token of the configuration the environment accepts
+func (r *ProviderConfigReconciler) checkEnvironment(ctx context.Context, obj *unstructured.Unstructured) (string, error) {
+	spec, err := controllers.GetProviderConfigSpec(obj)
+	if err != nil {
+		return "", err
+	}
+	mapData, err := controllers.GetProviderConfigData(r.Client, ctx, obj, spec)
+	if err != nil {
+		return "", err
+	}
+	envURL := fmt.Sprint(mapData["dt_env_url"])
+	err = controllers.CheckEnvironment(ctx, envURL, fmt.Sprint(mapData["dt_api_token"]))
+	if err == nil {
+		return controllers.APITokenPrimary, nil
+	}
+
+	secondaryToken, err2 := controllers.GetSecondaryAPIToken(r.Client, ctx, obj, spec)
+	if err2 != nil {
+		return "", err2
+	}
+	if secondaryToken == "" || controllers.CheckEnvironment(ctx, envURL, secondaryToken) != nil {
+		return "", err
+	}
+	return controllers.APITokenSecondary, nil
+}
+
+func (r *ProviderConfigReconciler) updateStatus(ctx context.Context, obj *unstructured.Unstructured, token string, checkErr error) error {
+	objGen := obj.GetGeneration()
+
+	var conditions []kmapi.Condition
+	phase := status.CurrentStatus
+	if checkErr == nil {
+		conditions = kmapi.SetCondition(conditions, kmapi.NewCondition(ConditionReady, "The environment is reachable", objGen, true))
+		if token == controllers.APITokenSecondary {
+			conditions = kmapi.SetCondition(conditions, kmapi.NewCondition(controllers.ConditionAPIToken, "The primary API token was rejected, the secondary API token is used", objGen, false))
+		}
+	} else {
+		conditions = kmapi.SetCondition(conditions, kmapi.NewCondition(ConditionReady, checkErr.Error(), objGen, false))
+		phase = status.FailedStatus
+	}
+
+	// store the conditions in the same form as they are read back from the api server
+	condByte, err := json.Marshal(conditions)
+	if err != nil {
+		return err
+	}
+	var condList []interface{}
+	err = json.Unmarshal(condByte, &condList)
+	if err != nil {
+		return err
+	}
+
+	err = unstructured.SetNestedSlice(obj.Object, condList, "status", "conditions")
+	if err != nil {
+		return err
+	}
+	err = unstructured.SetNestedField(obj.Object, objGen, "status", "observedGeneration")
+	if err != nil {
+		return err
+	}
+	err = unstructured.SetNestedField(obj.Object, string(phase), "status", "phase")
+	if err != nil {
+		return err
+	}
+
+	return r.Status().Update(ctx, obj)
+}
+
+func (r *ProviderConfigReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(r.Gvk)
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(obj, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&source.Kind{Type: &corev1.Secret{}}, handler.EnqueueRequestsFromMapFunc(r.secretReferences)).
+		Complete(r)
+}
+
+// secretReferences returns the configurations referencing the Secret, so that a rotated API token is checked
+// without waiting for the next check
+func (r *ProviderConfigReconciler) secretReferences(secret client.Object) []ctrl.Request {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(r.Gvk.GroupVersion().WithKind(r.Gvk.Kind + "List"))
+	var opts []client.ListOption
+	if r.Gvk.Kind == controllers.ProviderConfigKind {
+		// a ProviderConfig only reads the Secrets of its own namespace
+		opts = append(opts, client.InNamespace(secret.GetNamespace()))
+	}
+	if err := r.List(context.TODO(), list, opts...); err != nil {
+		klog.Errorf("unable to list %s referencing secret %s/%s: %v", r.Gvk.Kind, secret.GetNamespace(), secret.GetName(), err)
+		return nil
+	}
+
+	key := types.NamespacedName{Namespace: secret.GetNamespace(), Name: secret.GetName()}
+	var requests []ctrl.Request
+	for i := range list.Items {
+		pc := &list.Items[i]
+		spec, err := controllers.GetProviderConfigSpec(pc)
+		if err != nil {
+			continue
+		}
+		for _, ref := range controllers.GetProviderConfigSecrets(pc, spec) {
+			if ref == key {
+				requests = append(requests, ctrl.Request{NamespacedName: types.NamespacedName{Namespace: pc.GetNamespace(), Name: pc.GetName()}})
+				break
+			}
+		}
+	}
+	return requests
+}
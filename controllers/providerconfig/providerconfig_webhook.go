@@ -0,0 +1,126 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the AppsCode Community License 1.0.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://github.com/appscode/licenses/raw/1.0.0/AppsCode-Community-1.0.0.md
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package providerconfig
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"kubeform.dev/provider-dynatrace-controller/controllers"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// ProviderConfigValidator rejects an invalid ProviderConfig or ClusterProviderConfig, or one whose environment
+// rejects the configured API token. The ProviderConfigReconciler checks the environment again and publishes the
+// result as the Ready condition.
+type ProviderConfigValidator struct {
+	Client client.Client
+	Gvk    schema.GroupVersionKind
+
+	CheckTimeout time.Duration // how long the environment is waited for, within the timeout of the webhook
+}
+
+var _ admission.Handler = &ProviderConfigValidator{}
+
+func (v *ProviderConfigValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if req.Operation != admissionv1.Create && req.Operation != admissionv1.Update {
+		return admission.Allowed("")
+	}
+
+	obj := &unstructured.Unstructured{}
+	if err := obj.UnmarshalJSON(req.Object.Raw); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	spec, err := controllers.GetProviderConfigSpec(obj)
+	if err != nil {
+		return admission.Denied(err.Error())
+	}
+	if u, err := url.Parse(spec.EnvURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return admission.Denied(fmt.Sprintf("spec.envURL %q is not a valid url", spec.EnvURL))
+	}
+	if spec.APITokenSecretRef.Name == "" {
+		return admission.Denied("spec.apiTokenSecretRef.name is required")
+	}
+	if obj.GetNamespace() == "" && spec.APITokenSecretRef.Namespace == "" {
+		return admission.Denied("spec.apiTokenSecretRef.namespace is required")
+	}
+	if ref := spec.SecondaryAPITokenSecretRef; ref != nil {
+		if ref.Name == "" {
+			return admission.Denied("spec.secondaryAPITokenSecretRef.name is required")
+		}
+		if obj.GetNamespace() == "" && ref.Namespace == "" {
+			return admission.Denied("spec.secondaryAPITokenSecretRef.namespace is required")
+		}
+	}
+	if spec.Defaults != nil {
+		if p := spec.Defaults.DriftPolicy; p != "" && p != controllers.DriftPolicyEnforce && p != controllers.DriftPolicyReport {
+			return admission.Denied(fmt.Sprintf("spec.defaults.driftPolicy must be one of %s, %s", controllers.DriftPolicyEnforce, controllers.DriftPolicyReport))
+		}
+		if p := spec.Defaults.TerminationPolicy; p != "" {
+			if err := controllers.ValidateTerminationPolicy(p); err != nil {
+				return admission.Denied(fmt.Sprintf("spec.defaults.terminationPolicy: %v", err))
+			}
+		}
+	}
+
+	// a slow or unreachable environment only warns, so that it doesn't fail the admission
+	mapData, err := controllers.GetProviderConfigData(v.Client, ctx, obj, spec)
+	if err != nil {
+		return admission.Allowed("").WithWarnings(fmt.Sprintf("unable to read the API token: %v", err))
+	}
+	checkCtx, cancel := context.WithTimeout(ctx, v.CheckTimeout)
+	defer cancel()
+	envURL := fmt.Sprint(mapData["dt_env_url"])
+	err = controllers.CheckEnvironment(checkCtx, envURL, fmt.Sprint(mapData["dt_api_token"]))
+	if err == nil {
+		return admission.Allowed("")
+	}
+	if !controllers.IsTokenRejected(err) {
+		return admission.Allowed("").WithWarnings(fmt.Sprintf("unable to check the API token: %v", err))
+	}
+
+	// the primary API token may already be revoked while the tokens are rotated
+	secondaryToken, err2 := controllers.GetSecondaryAPIToken(v.Client, ctx, obj, spec)
+	if err2 != nil || secondaryToken == "" {
+		return admission.Denied(fmt.Sprintf("the environment doesn't accept the API token: %v", err))
+	}
+	err2 = controllers.CheckEnvironment(checkCtx, envURL, secondaryToken)
+	if controllers.IsTokenRejected(err2) {
+		return admission.Denied(fmt.Sprintf("the environment doesn't accept the API token nor the secondary API token: %v", err))
+	}
+	if err2 != nil {
+		return admission.Allowed("").WithWarnings(fmt.Sprintf("the environment doesn't accept the API token, unable to check the secondary API token: %v", err2))
+	}
+	return admission.Allowed("").WithWarnings(fmt.Sprintf("the environment doesn't accept the API token, the secondary API token is used: %v", err))
+}
+
+func (v *ProviderConfigValidator) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	path := "/validate-" + strings.ReplaceAll(strings.ToLower(v.Gvk.Group), ".", "-") + "-" + v.Gvk.Version + "-" + strings.ToLower(v.Gvk.Kind)
+	mgr.GetWebhookServer().Register(path, &webhook.Admission{Handler: v})
+	return nil
+}
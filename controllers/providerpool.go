@@ -0,0 +1,178 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the AppsCode Community License 1.0.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://github.com/appscode/licenses/raw/1.0.0/AppsCode-Community-1.0.0.md
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"sync"
+	"time"
+
+	tfschema "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// providerIdleTimeout is the time after which a server which wasn't used is removed from the pool,
+// e.g. after the credentials it was configured with have been rotated
+const providerIdleTimeout = 30 * time.Minute
+
+// newProvider returns a new instance of the provider, set by SetupProviderPool
+var newProvider func() *tfschema.Provider
+
+// SetupProviderPool sets the function creating the provider instances of the pool. Every configuration
+// gets its own instance, so that concurrent reconciles against different tenants don't share the meta
+// of the provider.
+func SetupProviderPool(fn func() *tfschema.Provider) {
+	newProvider = fn
+}
+
+// pooledServer is a provider server configured once and reused by the reconciles using the same configuration
+type pooledServer struct {
+	once     sync.Once
+	server   *tfschema.GRPCProviderServer
+	token    string   // API token of the configuration used by the server, if it has a secondary API token
+	hosts    []string // hosts of the tenants of the configuration, the calls of the server are limited per tenant
+	err      error
+	lastUsed time.Time
+}
+
+// providerServers holds the configured servers keyed by the hash of their configuration
+var providerServers = struct {
+	sync.Mutex
+	mp map[string]*pooledServer
+}{mp: make(map[string]*pooledServer)}
+
+// getPooledServer returns the server configured with the given provider configuration, keyed by the
+// terraform attribute names. The server is created and configured on first use, with the secondary API
+// token if the environment rejects the API token of the configuration.
+func getPooledServer(ctx context.Context, provider *tfschema.Provider, mapData map[string]interface{}, secondaryToken string) (*tfschema.GRPCProviderServer, string, error) {
+	key, err := providerConfigHash(mapData, secondaryToken)
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now()
+	providerServers.Lock()
+	for k, ps := range providerServers.mp {
+		if now.Sub(ps.lastUsed) > providerIdleTimeout {
+			delete(providerServers.mp, k)
+		}
+	}
+	ps, ok := providerServers.mp[key]
+	if !ok {
+		ps = &pooledServer{}
+		providerServers.mp[key] = ps
+	}
+	ps.lastUsed = now
+	providerServers.Unlock()
+
+	ps.once.Do(func() {
+		p := provider
+		if newProvider != nil {
+			p = newProvider()
+		}
+		server := tfschema.NewGRPCProviderServer(p)
+		configData, token := selectAPIToken(ctx, mapData, secondaryToken)
+		err := ConfigureProvider(ctx, p, server, configData)
+
+		// forgetRejectedServer looks up the servers of the other entries while they are configured
+		providerServers.Lock()
+		ps.server, ps.token, ps.err = server, token, err
+		ps.hosts = tenantHosts(mapData)
+		providerServers.Unlock()
+	})
+	if ps.err != nil {
+		// failures are not cached, so that the next reconcile configures a new server
+		providerServers.Lock()
+		if providerServers.mp[key] == ps {
+			delete(providerServers.mp, key)
+		}
+		providerServers.Unlock()
+		return nil, "", ps.err
+	}
+	return ps.server, ps.token, nil
+}
+
+// forgetRejectedServer removes the server from the pool if it uses the primary API token of a configuration
+// which has a secondary API token, so that the next reconcile falls back to the secondary API token
+func forgetRejectedServer(server *tfschema.GRPCProviderServer) bool {
+	providerServers.Lock()
+	defer providerServers.Unlock()
+
+	for k, ps := range providerServers.mp {
+		if ps.server == server && ps.token == APITokenPrimary {
+			delete(providerServers.mp, k)
+			return true
+		}
+	}
+	return false
+}
+
+// getTenantHosts returns the hosts of the tenants of the configuration of the server
+func getTenantHosts(server *tfschema.GRPCProviderServer) []string {
+	providerServers.Lock()
+	defer providerServers.Unlock()
+
+	for _, ps := range providerServers.mp {
+		if ps.server == server {
+			return ps.hosts
+		}
+	}
+	return nil
+}
+
+// isTenantHost returns true if the host is a tenant of one of the configurations of the pool
+func isTenantHost(host string) bool {
+	providerServers.Lock()
+	defer providerServers.Unlock()
+
+	for _, ps := range providerServers.mp {
+		for _, h := range ps.hosts {
+			if h == host {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// tenantHosts returns the hosts of the environment and cluster urls of the provider configuration
+func tenantHosts(mapData map[string]interface{}) []string {
+	var hosts []string
+	for _, key := range []string{"dt_env_url", "dt_cluster_url"} {
+		val, ok := mapData[key].(string)
+		if !ok || val == "" {
+			continue
+		}
+		if u, err := url.Parse(val); err == nil && u.Host != "" {
+			hosts = append(hosts, u.Host)
+		}
+	}
+	return hosts
+}
+
+// providerConfigHash returns the key of the provider configuration in the pool, without keeping the credentials
+func providerConfigHash(mapData map[string]interface{}, secondaryToken string) (string, error) {
+	data, err := json.Marshal(mapData)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(append(data, secondaryToken...))
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,547 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the AppsCode Community License 1.0.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://github.com/appscode/licenses/raw/1.0.0/AppsCode-Community-1.0.0.md
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	kmapi "kmodules.xyz/client-go/api/v1"
+	"sigs.k8s.io/cli-utils/pkg/kstatus/status"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// ReferencesKey is the annotation holding the references of an object to other objects, e.g.
+	// [{"fieldPath": "mzID", "kind": "Zone", "name": "production"}]
+	ReferencesKey = "dynatrace.kubeform.com/references"
+	// UsedByKey is the annotation holding the objects which reference an object
+	UsedByKey = "dynatrace.kubeform.com/used-by"
+
+	ConditionWaitingForReference = "WaitingForReference"
+	ConditionDeletionBlocked     = "DeletionBlocked"
+
+	waitRequeueInterval = 10 * time.Second
+)
+
+// ObjectReference sets a field of spec.resource to the spec.resource.id of another object
+// in the same namespace. The object is either selected by name or by labels.
+type ObjectReference struct {
+	// FieldPath is the path of the field in spec.resource, e.g. rules[0].managementZone
+	FieldPath string `json:"fieldPath"`
+	// SourceFieldPath is the path of the value in the referenced object, spec.resource.id by default.
+	// It allows using the result of a data source, e.g. status.output.id
+	SourceFieldPath string `json:"sourceFieldPath,omitempty"`
+
+	APIVersion string                `json:"apiVersion,omitempty"`
+	Kind       string                `json:"kind"`
+	Name       string                `json:"name,omitempty"`
+	Selector   *metav1.LabelSelector `json:"selector,omitempty"`
+}
+
+// dependent is an object which references another object
+type dependent struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+}
+
+// waitError is returned when an object can't be reconciled until another object changes
+type waitError struct {
+	condition string
+	msg       string
+}
+
+func (e *waitError) Error() string {
+	return e.msg
+}
+
+func getReferences(obj *unstructured.Unstructured) ([]ObjectReference, error) {
+	val, ok := obj.GetAnnotations()[ReferencesKey]
+	if !ok {
+		return nil, nil
+	}
+
+	var refs []ObjectReference
+	if err := json.Unmarshal([]byte(val), &refs); err != nil {
+		return nil, fmt.Errorf("failed to parse annotation %s: %v", ReferencesKey, err)
+	}
+	for _, ref := range refs {
+		if ref.FieldPath == "" || ref.Kind == "" {
+			return nil, fmt.Errorf("annotation %s: fieldPath and kind are required", ReferencesKey)
+		}
+		if (ref.Name == "") == (ref.Selector == nil) {
+			return nil, fmt.Errorf("annotation %s: exactly one of name and selector must be set for fieldPath %s", ReferencesKey, ref.FieldPath)
+		}
+	}
+	return refs, nil
+}
+
+// resolveReferences sets the fields of rawSpec pointed by the references of obj to the id of the referenced objects
+func resolveReferences(rClient client.Client, ctx context.Context, obj *unstructured.Unstructured, resType reflect.Type, rawSpec map[string]interface{}) error {
+	// the references are not needed to destroy the object, so don't wait for them
+	if obj.GetDeletionTimestamp() != nil {
+		return nil
+	}
+
+	refs, err := getReferences(obj)
+	if err != nil {
+		return err
+	}
+
+	for _, ref := range refs {
+		target, err := getReferencedObject(rClient, ctx, obj, ref)
+		if err != nil {
+			return err
+		}
+
+		sourceFieldPath := ref.SourceFieldPath
+		if sourceFieldPath == "" {
+			sourceFieldPath = "spec.resource.id"
+		}
+		id, err := getFieldValue(target.Object, sourceFieldPath)
+		if err != nil {
+			return err
+		}
+		if id == "" {
+			return &waitError{
+				condition: ConditionWaitingForReference,
+				msg:       fmt.Sprintf("waiting for %s of %s %s referenced by %s to be set", sourceFieldPath, target.GetKind(), target.GetName(), ref.FieldPath),
+			}
+		}
+
+		steps, err := resourceAttributePath(resType, ref.FieldPath)
+		if err != nil {
+			return err
+		}
+		if _, err = setAttribute(rawSpec, steps, id); err != nil {
+			return fmt.Errorf("failed to set %s: %v", ref.FieldPath, err)
+		}
+
+		err = addDependent(rClient, ctx, target, obj)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func getReferenceGVK(rClient client.Client, ref ObjectReference) (schema.GroupVersionKind, error) {
+	if ref.APIVersion != "" {
+		gv, err := schema.ParseGroupVersion(ref.APIVersion)
+		if err != nil {
+			return schema.GroupVersionKind{}, err
+		}
+		return gv.WithKind(ref.Kind), nil
+	}
+
+	var gvks []schema.GroupVersionKind
+	for gvk := range rClient.Scheme().AllKnownTypes() {
+		if gvk.Kind == ref.Kind && strings.HasSuffix(gvk.Group, "dynatrace.kubeform.com") {
+			gvks = append(gvks, gvk)
+		}
+	}
+	if len(gvks) != 1 {
+		return schema.GroupVersionKind{}, fmt.Errorf("can't determine the apiVersion of kind %s referenced by %s, set apiVersion of the reference", ref.Kind, ref.FieldPath)
+	}
+	return gvks[0], nil
+}
+
+func getReferencedObject(rClient client.Client, ctx context.Context, obj *unstructured.Unstructured, ref ObjectReference) (*unstructured.Unstructured, error) {
+	gvk, err := getReferenceGVK(rClient, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	if ref.Name != "" {
+		target := &unstructured.Unstructured{}
+		target.SetGroupVersionKind(gvk)
+		err = rClient.Get(ctx, types.NamespacedName{Namespace: obj.GetNamespace(), Name: ref.Name}, target)
+		if errors.IsNotFound(err) {
+			return nil, &waitError{
+				condition: ConditionWaitingForReference,
+				msg:       fmt.Sprintf("waiting for %s %s referenced by %s to exist", ref.Kind, ref.Name, ref.FieldPath),
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		return target, nil
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(ref.Selector)
+	if err != nil {
+		return nil, err
+	}
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+	err = rClient.List(ctx, list, client.InNamespace(obj.GetNamespace()), client.MatchingLabelsSelector{Selector: selector})
+	if err != nil {
+		return nil, err
+	}
+	if len(list.Items) == 0 {
+		return nil, &waitError{
+			condition: ConditionWaitingForReference,
+			msg:       fmt.Sprintf("waiting for a %s matching %s referenced by %s to exist", ref.Kind, selector.String(), ref.FieldPath),
+		}
+	}
+	// pick the same object every time when more than one matches
+	sort.Slice(list.Items, func(i, j int) bool {
+		return list.Items[i].GetName() < list.Items[j].GetName()
+	})
+	return &list.Items[0], nil
+}
+
+// getFieldValue returns the string at the field path of obj, or an empty string if it isn't set
+func getFieldValue(obj map[string]interface{}, fieldPath string) (string, error) {
+	var val interface{} = obj
+	for _, field := range strings.Split(strings.NewReplacer("[", ".", "]", "").Replace(fieldPath), ".") {
+		switch v := val.(type) {
+		case map[string]interface{}:
+			val = v[field]
+		case []interface{}:
+			idx, err := strconv.Atoi(field)
+			if err != nil {
+				return "", fmt.Errorf("index %s of %s must be a number", field, fieldPath)
+			}
+			if idx >= len(v) {
+				return "", nil
+			}
+			val = v[idx]
+		default:
+			return "", nil
+		}
+	}
+
+	switch v := val.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	default:
+		return "", fmt.Errorf("%s is not a string", fieldPath)
+	}
+}
+
+// resourceAttributePath converts a field path of spec.resource into the attribute path of the terraform resource
+func resourceAttributePath(t reflect.Type, fieldPath string) ([]string, error) {
+	var steps []string
+	fields := strings.Split(strings.NewReplacer("[", ".", "]", "").Replace(fieldPath), ".")
+	for _, field := range fields {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+
+		switch t.Kind() {
+		case reflect.Struct:
+			var fieldType reflect.Type
+			for i := 0; i < t.NumField(); i++ {
+				f := t.Field(i)
+				if strings.Split(f.Tag.Get("json"), ",")[0] == field {
+					steps = append(steps, strings.Split(f.Tag.Get("tf"), ",")[0])
+					fieldType = f.Type
+					break
+				}
+			}
+			if fieldType == nil {
+				return nil, fmt.Errorf("field %s of fieldPath %s doesn't exist", field, fieldPath)
+			}
+			if fieldType.Kind() == reflect.Ptr && fieldType.Elem().Kind() == reflect.Struct {
+				// blocks with at most one element are represented as a list with one element in terraform
+				steps = append(steps, "0")
+			}
+			t = fieldType
+		case reflect.Slice, reflect.Array:
+			if _, err := strconv.Atoi(field); err != nil {
+				return nil, fmt.Errorf("index %s of fieldPath %s must be a number", field, fieldPath)
+			}
+			steps = append(steps, field)
+			t = t.Elem()
+		case reflect.Map:
+			steps = append(steps, field)
+			t = t.Elem()
+		default:
+			return nil, fmt.Errorf("field %s of fieldPath %s doesn't exist", field, fieldPath)
+		}
+	}
+	return steps, nil
+}
+
+// setAttribute sets the value at the attribute path of data, creating the missing maps and lists on the way
+func setAttribute(data interface{}, steps []string, value interface{}) (interface{}, error) {
+	if len(steps) == 0 {
+		return value, nil
+	}
+
+	step := steps[0]
+	idx, idxErr := strconv.Atoi(step)
+	switch d := data.(type) {
+	case nil:
+		v, err := setAttribute(nil, steps[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		if idxErr == nil {
+			if idx != 0 {
+				return nil, fmt.Errorf("index %d is out of range", idx)
+			}
+			return []interface{}{v}, nil
+		}
+		return map[string]interface{}{step: v}, nil
+	case []interface{}:
+		if idxErr != nil || idx > len(d) {
+			return nil, fmt.Errorf("index %s is out of range", step)
+		}
+		if idx == len(d) {
+			d = append(d, nil)
+		}
+		v, err := setAttribute(d[idx], steps[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		d[idx] = v
+		return d, nil
+	case map[string]interface{}:
+		v, err := setAttribute(d[step], steps[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		d[step] = v
+		return d, nil
+	default:
+		return nil, fmt.Errorf("%s is not a block", step)
+	}
+}
+
+func getDependents(obj *unstructured.Unstructured) ([]dependent, error) {
+	val, ok := obj.GetAnnotations()[UsedByKey]
+	if !ok {
+		return nil, nil
+	}
+
+	var deps []dependent
+	if err := json.Unmarshal([]byte(val), &deps); err != nil {
+		return nil, fmt.Errorf("failed to parse annotation %s: %v", UsedByKey, err)
+	}
+	return deps, nil
+}
+
+// updateDependents re-reads obj and patches the annotation holding its dependents with the result of mutate.
+// The patch is rejected if obj changed since it was read, so that the dependents added or removed at the
+// same time by the reconciles of other objects are not lost. It returns the dependents which were written.
+func updateDependents(rClient client.Client, ctx context.Context, obj *unstructured.Unstructured, mutate func([]dependent) []dependent) ([]dependent, error) {
+	var deps []dependent
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		latest := &unstructured.Unstructured{}
+		latest.SetGroupVersionKind(obj.GroupVersionKind())
+		err := rClient.Get(ctx, client.ObjectKeyFromObject(obj), latest)
+		if err != nil {
+			return err
+		}
+
+		current, err := getDependents(latest)
+		if err != nil {
+			return err
+		}
+		deps = mutate(current)
+		if reflect.DeepEqual(deps, current) {
+			obj.SetAnnotations(latest.GetAnnotations())
+			return nil
+		}
+
+		patch := client.MergeFromWithOptions(latest.DeepCopy(), client.MergeFromWithOptimisticLock{})
+		annotations := latest.GetAnnotations()
+		if len(deps) == 0 {
+			delete(annotations, UsedByKey)
+		} else {
+			val, err := json.Marshal(deps)
+			if err != nil {
+				return err
+			}
+			if annotations == nil {
+				annotations = make(map[string]string)
+			}
+			annotations[UsedByKey] = string(val)
+		}
+		latest.SetAnnotations(annotations)
+
+		err = rClient.Patch(ctx, latest, patch)
+		if err != nil {
+			return err
+		}
+		obj.SetAnnotations(latest.GetAnnotations())
+		obj.SetResourceVersion(latest.GetResourceVersion())
+		return nil
+	})
+	return deps, err
+}
+
+// addDependent records on target that it is referenced by obj
+func addDependent(rClient client.Client, ctx context.Context, target *unstructured.Unstructured, obj *unstructured.Unstructured) error {
+	dep := dependent{
+		APIVersion: obj.GetAPIVersion(),
+		Kind:       obj.GetKind(),
+		Name:       obj.GetName(),
+	}
+
+	deps, err := getDependents(target)
+	if err != nil {
+		return err
+	}
+	for _, d := range deps {
+		if d == dep {
+			return nil
+		}
+	}
+
+	_, err = updateDependents(rClient, ctx, target, func(deps []dependent) []dependent {
+		for _, d := range deps {
+			if d == dep {
+				return deps
+			}
+		}
+		return append(deps, dep)
+	})
+	return err
+}
+
+// isReferencedBy returns true if any of the references of dep points to obj
+func isReferencedBy(rClient client.Client, obj *unstructured.Unstructured, dep *unstructured.Unstructured) bool {
+	refs, err := getReferences(dep)
+	if err != nil {
+		// keep protecting obj as long as the references can't be read
+		return true
+	}
+
+	for _, ref := range refs {
+		gvk, err := getReferenceGVK(rClient, ref)
+		if err != nil || gvk != obj.GroupVersionKind() {
+			continue
+		}
+		if ref.Name != "" {
+			if ref.Name == obj.GetName() {
+				return true
+			}
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(ref.Selector)
+		if err == nil && selector.Matches(labels.Set(obj.GetLabels())) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkDependents returns an error if obj is still referenced by other objects. Objects
+// which have been deleted or no longer reference obj are removed from its dependents.
+func checkDependents(rClient client.Client, ctx context.Context, obj *unstructured.Unstructured) error {
+	deps, err := getDependents(obj)
+	if err != nil {
+		return err
+	}
+
+	unused := make(map[dependent]bool)
+	for _, d := range deps {
+		dep := &unstructured.Unstructured{}
+		dep.SetAPIVersion(d.APIVersion)
+		dep.SetKind(d.Kind)
+		err := rClient.Get(ctx, types.NamespacedName{Namespace: obj.GetNamespace(), Name: d.Name}, dep)
+		if errors.IsNotFound(err) {
+			unused[d] = true
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if !isReferencedBy(rClient, obj, dep) {
+			unused[d] = true
+		}
+	}
+
+	remaining := deps
+	if len(unused) > 0 {
+		// the dependents added since obj was read are kept, they are checked by the next reconcile
+		remaining, err = updateDependents(rClient, ctx, obj, func(deps []dependent) []dependent {
+			var remaining []dependent
+			for _, d := range deps {
+				if !unused[d] {
+					remaining = append(remaining, d)
+				}
+			}
+			return remaining
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(remaining) > 0 {
+		inUse := make([]string, 0, len(remaining))
+		for _, d := range remaining {
+			inUse = append(inUse, d.Kind+" "+d.Name)
+		}
+		return &waitError{
+			condition: ConditionDeletionBlocked,
+			msg:       fmt.Sprintf("%s can't be deleted while it is referenced by %s", obj.GetName(), strings.Join(inUse, ", ")),
+		}
+	}
+	return nil
+}
+
+// waitUpdateStatus records why the object is waiting as a condition of the object
+func waitUpdateStatus(rClient client.Client, ctx context.Context, gv schema.GroupVersion, obj *unstructured.Unstructured, werr *waitError) error {
+	objGen, _, err := unstructured.NestedInt64(obj.Object, "metadata", "generation")
+	if err != nil {
+		return err
+	}
+
+	conditions, err := getConditions(gv, obj)
+	if err != nil {
+		return err
+	}
+	conditions = kmapi.RemoveCondition(conditions, "Reconciling")
+	conditions = kmapi.RemoveCondition(conditions, "Stalled")
+	conditions = removeDiagnosticConditions(conditions)
+	conditions = kmapi.SetCondition(conditions, kmapi.NewCondition(werr.condition, werr.msg, objGen))
+	if err = setDiagnostics(rClient, ctx, obj, nil); err != nil {
+		return err
+	}
+
+	err = setNestedFieldNoCopy(obj.Object, conditions, "status", "conditions")
+	if err != nil {
+		return err
+	}
+	if err = rClient.Status().Update(ctx, obj); err != nil {
+		return err
+	}
+
+	return updateStatus(rClient, ctx, obj, status.InProgressStatus)
+}
@@ -0,0 +1,316 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the AppsCode Community License 1.0.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://github.com/appscode/licenses/raw/1.0.0/AppsCode-Community-1.0.0.md
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	errors2 "errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tfschema "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"golang.org/x/time/rate"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	ConditionRetrying = "Retrying"
+
+	minRetryDelay = 5 * time.Second
+	maxRetryDelay = 5 * time.Minute
+)
+
+// statusCodes maps the status texts to the status codes, the provider reports the status text of the
+// responses without a body
+var statusCodes = func() map[string]int {
+	codes := make(map[string]int)
+	for code := 100; code < 600; code++ {
+		if text := http.StatusText(code); text != "" {
+			codes[text] = code
+		}
+	}
+	return codes
+}()
+
+// failedRequest is a request of the provider which got no response or an error response
+type failedRequest struct {
+	statusCode int    // status code of the response, 0 if the request got no response
+	host       string // host of the request, empty if the error doesn't tell
+}
+
+// parseFailedRequest parses the error of a request of the provider. The rest client of the provider returns
+// the error envelope of the Dynatrace API in json, or the status text, method and url of the request if
+// the response has no body. A request which got no response fails with a url.Error.
+func parseFailedRequest(msg string) (failedRequest, bool) {
+	var envelope struct {
+		Code int `json:"code"`
+	}
+	if err := json.Unmarshal([]byte(msg), &envelope); err == nil && envelope.Code != 0 {
+		return failedRequest{statusCode: envelope.Code}, true
+	}
+
+	// <status text> (<method>) <url>
+	if i := strings.Index(msg, " ("); i > 0 {
+		if code, ok := statusCodes[msg[:i]]; ok {
+			if j := strings.Index(msg[i:], ") "); j > 0 {
+				if u, err := url.Parse(msg[i+j+2:]); err == nil && u.Host != "" {
+					return failedRequest{statusCode: code, host: u.Host}, true
+				}
+			}
+		}
+	}
+
+	// <method> "<url>": <error>
+	if i := strings.Index(msg, " \""); i > 0 && isHTTPMethod(strings.ToUpper(msg[:i])) {
+		if j := strings.Index(msg[i+2:], "\": "); j > 0 {
+			if u, err := url.Parse(msg[i+2 : i+2+j]); err == nil && u.Host != "" {
+				return failedRequest{host: u.Host}, true
+			}
+		}
+	}
+	return failedRequest{}, false
+}
+
+func isHTTPMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// failedRequests returns the failed requests of the provider which caused err
+func failedRequests(err error) []failedRequest {
+	var msgs []string
+	var derr *diagnosticsError
+	if errors2.As(err, &derr) {
+		for _, d := range derr.diags {
+			msgs = append(msgs, d.Summary)
+		}
+	} else {
+		msgs = append(msgs, err.Error())
+	}
+
+	var requests []failedRequest
+	for _, msg := range msgs {
+		if req, ok := parseFailedRequest(msg); ok {
+			requests = append(requests, req)
+		}
+	}
+	return requests
+}
+
+// isTransientError returns true if the request failed because of the load of the tenant or a network
+// problem, in which case it is retried instead of marking the object as failed
+func isTransientError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var netErr net.Error
+	if errors2.As(err, &netErr) || errors2.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	for _, req := range failedRequests(err) {
+		if req.statusCode == 0 || req.statusCode == http.StatusTooManyRequests || req.statusCode >= http.StatusInternalServerError {
+			return true
+		}
+	}
+	return false
+}
+
+// retryFailures counts the consecutive transient failures of every object
+var retryFailures = struct {
+	sync.Mutex
+	mp map[types.UID]int
+}{mp: make(map[types.UID]int)}
+
+// nextRetryDelay returns the exponential backoff of the object, honoring the Retry-After of the tenant
+func nextRetryDelay(uid types.UID, err error) time.Duration {
+	retryFailures.Lock()
+	retryFailures.mp[uid]++
+	failures := retryFailures.mp[uid]
+	retryFailures.Unlock()
+
+	delay := maxRetryDelay
+	if failures < 16 {
+		delay = minRetryDelay * time.Duration(1<<uint(failures-1))
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	if retryAfter := getRetryAfter(err); retryAfter > delay {
+		delay = retryAfter
+	}
+	// spread the retries of objects which failed at the same time
+	return wait.Jitter(delay, 0.1)
+}
+
+// resetRetryDelay forgets the failures of the object after a successful reconcile
+func resetRetryDelay(uid types.UID) {
+	retryFailures.Lock()
+	delete(retryFailures.mp, uid)
+	retryFailures.Unlock()
+}
+
+// tenantLimiter limits the calls of the provider sent to a Dynatrace tenant
+type tenantLimiter struct {
+	limiter    *rate.Limiter
+	retryAfter time.Time // calls are held back until then after the tenant responded with Retry-After
+}
+
+// tenantLimiters holds a token bucket for every Dynatrace tenant, shared by all the controllers and by all the
+// configurations of the provider using the tenant, so that applying many objects at once doesn't get the
+// tenant to throttle the requests
+var tenantLimiters = struct {
+	sync.Mutex
+	qps   rate.Limit
+	burst int
+	mp    map[string]*tenantLimiter
+}{qps: rate.Inf, burst: 1, mp: make(map[string]*tenantLimiter)}
+
+// SetupRateLimiting sets the rate of the calls of the provider sent to every Dynatrace tenant, and wraps
+// http.DefaultTransport to hold back the calls to a tenant which responded with Retry-After. The rest client
+// of the provider creates its http client for every service without a way to pass a transport, so the
+// default transport is the only one its requests go through. The wrapper only looks at the responses of the
+// tenants of the provider configurations; the clients the provider creates with their own transport, i.e.
+// the insecure clients of the cluster API, are not covered.
+func SetupRateLimiting(qps float64, burst int) {
+	tenantLimiters.Lock()
+	tenantLimiters.qps = rate.Limit(qps)
+	if qps <= 0 {
+		tenantLimiters.qps = rate.Inf
+	}
+	tenantLimiters.burst = burst
+	if burst < 1 {
+		tenantLimiters.burst = 1
+	}
+	tenantLimiters.Unlock()
+
+	http.DefaultTransport = &RetryAfterTransport{base: http.DefaultTransport}
+}
+
+func getTenantLimiter(host string) *tenantLimiter {
+	tenantLimiters.Lock()
+	defer tenantLimiters.Unlock()
+
+	tl, ok := tenantLimiters.mp[host]
+	if !ok {
+		tl = &tenantLimiter{limiter: rate.NewLimiter(tenantLimiters.qps, tenantLimiters.burst)}
+		tenantLimiters.mp[host] = tl
+	}
+	return tl
+}
+
+// waitForTenant waits until the server may send a call to the tenants of its configuration. It takes one
+// token of the limiter of every tenant per call of the provider, e.g. per ReadResource or ApplyResourceChange,
+// which may send several requests to the tenant, so the limit is a rate of calls rather than of requests.
+func waitForTenant(ctx context.Context, server *tfschema.GRPCProviderServer) error {
+	for _, host := range getTenantHosts(server) {
+		tl := getTenantLimiter(host)
+
+		tenantLimiters.Lock()
+		holdBack := time.Until(tl.retryAfter)
+		tenantLimiters.Unlock()
+		if holdBack > 0 {
+			timer := time.NewTimer(holdBack)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+
+		if err := tl.limiter.Wait(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RetryAfterTransport holds back the calls to a tenant which responded with Retry-After
+type RetryAfterTransport struct {
+	base http.RoundTripper
+}
+
+var _ http.RoundTripper = &RetryAfterTransport{}
+
+func (t *RetryAfterTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if (resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable) && isTenantHost(req.URL.Host) {
+		if retryAfter := parseRetryAfter(resp.Header.Get("Retry-After")); retryAfter > 0 {
+			tl := getTenantLimiter(req.URL.Host)
+			tenantLimiters.Lock()
+			if until := time.Now().Add(retryAfter); until.After(tl.retryAfter) {
+				tl.retryAfter = until
+			}
+			tenantLimiters.Unlock()
+		}
+	}
+	return resp, nil
+}
+
+// getRetryAfter returns how long the tenant asked to wait before sending the failed request again. The
+// tenant is the host of the failed request, or any tenant if the error doesn't tell.
+func getRetryAfter(err error) time.Duration {
+	if err == nil {
+		return 0
+	}
+
+	hosts := make(map[string]bool)
+	for _, req := range failedRequests(err) {
+		if req.host != "" {
+			hosts[req.host] = true
+		}
+	}
+
+	tenantLimiters.Lock()
+	defer tenantLimiters.Unlock()
+
+	var until time.Time
+	for h, tl := range tenantLimiters.mp {
+		if (len(hosts) == 0 || hosts[h]) && tl.retryAfter.After(until) {
+			until = tl.retryAfter
+		}
+	}
+	return time.Until(until)
+}
+
+// parseRetryAfter parses the value of the Retry-After header, either in seconds or as a date
+func parseRetryAfter(val string) time.Duration {
+	if val == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(val); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(val); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
@@ -0,0 +1,216 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the AppsCode Community License 1.0.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://github.com/appscode/licenses/raw/1.0.0/AppsCode-Community-1.0.0.md
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const testTenant = "abc12345.live.dynatrace.com"
+
+func TestParseFailedRequest(t *testing.T) {
+	tests := []struct {
+		msg    string
+		want   failedRequest
+		wantOk bool
+	}{
+		{
+			msg:    "{\n  \"code\": 429,\n  \"message\": \"Too many requests\"\n}",
+			want:   failedRequest{statusCode: http.StatusTooManyRequests},
+			wantOk: true,
+		},
+		{
+			msg:    "Too Many Requests (GET) https://" + testTenant + "/api/config/v1/dashboards",
+			want:   failedRequest{statusCode: http.StatusTooManyRequests, host: testTenant},
+			wantOk: true,
+		},
+		{
+			msg:    "Service Unavailable (PUT) https://" + testTenant + "/api/config/v1/dashboards/1",
+			want:   failedRequest{statusCode: http.StatusServiceUnavailable, host: testTenant},
+			wantOk: true,
+		},
+		{
+			msg:    "Not Found (DELETE) https://" + testTenant + "/api/config/v1/dashboards/1",
+			want:   failedRequest{statusCode: http.StatusNotFound, host: testTenant},
+			wantOk: true,
+		},
+		{
+			msg:    "Get \"https://" + testTenant + "/api/v2/settings/objects\": dial tcp: lookup " + testTenant + ": no such host",
+			want:   failedRequest{host: testTenant},
+			wantOk: true,
+		},
+		{msg: "Missing required argument (name)"},
+		{msg: `{"message": "no code"}`},
+	}
+	for _, tt := range tests {
+		got, ok := parseFailedRequest(tt.msg)
+		if ok != tt.wantOk || got != tt.want {
+			t.Errorf("parseFailedRequest(%q) = %+v, %v, want %+v, %v", tt.msg, got, ok, tt.want, tt.wantOk)
+		}
+	}
+}
+
+func TestIsTransientError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "no error"},
+		{name: "too many requests", err: errors.New(`{"code": 429, "message": "Too many requests"}`), want: true},
+		{name: "server error", err: fmt.Errorf("Internal Server Error (POST) https://%s/api/config/v1/dashboards", testTenant), want: true},
+		{name: "service unavailable", err: fmt.Errorf("Service Unavailable (GET) https://%s/api/config/v1/dashboards", testTenant), want: true},
+		{name: "bad request", err: errors.New(`{"code": 400, "message": "Constraints violated"}`)},
+		{name: "not found", err: fmt.Errorf("Not Found (GET) https://%s/api/config/v1/dashboards/1", testTenant)},
+		{name: "unauthorized", err: fmt.Errorf("Unauthorized (GET) https://%s/api/config/v1/dashboards", testTenant)},
+		{name: "no response", err: fmt.Errorf("Get \"https://%s/api/config/v1/dashboards\": EOF", testTenant), want: true},
+		{
+			name: "network error",
+			err:  &url.Error{Op: "Get", URL: "https://" + testTenant, Err: &net.DNSError{Err: "i/o timeout", Name: testTenant, IsTimeout: true}},
+			want: true,
+		},
+		{name: "deadline exceeded", err: fmt.Errorf("failed to read: %w", context.DeadlineExceeded), want: true},
+		{
+			name: "diagnostics of the provider",
+			err: &diagnosticsError{diags: []*tfprotov5.Diagnostic{{
+				Severity: tfprotov5.DiagnosticSeverityError,
+				Summary:  "Too Many Requests (GET) https://" + testTenant + "/api/config/v1/dashboards",
+			}}},
+			want: true,
+		},
+		{name: "invalid configuration", err: errors.New("Missing required argument (name)")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isTransientError(tt.err); got != tt.want {
+				t.Errorf("isTransientError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextRetryDelay(t *testing.T) {
+	uid := types.UID("retry-test")
+	t.Cleanup(func() { resetRetryDelay(uid) })
+	err := errors.New(`{"code": 503, "message": "Service Unavailable"}`)
+
+	for i, want := range []time.Duration{minRetryDelay, 2 * minRetryDelay, 4 * minRetryDelay} {
+		if got := nextRetryDelay(uid, err); got < want || got > want+want/10 {
+			t.Errorf("delay of failure %d = %s, want %s plus jitter", i+1, got, want)
+		}
+	}
+	for i := 0; i < 20; i++ {
+		nextRetryDelay(uid, err)
+	}
+	if got := nextRetryDelay(uid, err); got < maxRetryDelay || got > maxRetryDelay+maxRetryDelay/10 {
+		t.Errorf("delay after many failures = %s, want %s plus jitter", got, maxRetryDelay)
+	}
+
+	resetRetryDelay(uid)
+	if got := nextRetryDelay(uid, err); got > minRetryDelay+minRetryDelay/10 {
+		t.Errorf("delay after a reset = %s, want %s plus jitter", got, minRetryDelay)
+	}
+
+	// the Retry-After of the tenant wins over a shorter backoff
+	resetRetryDelay(uid)
+	tl := getTenantLimiter(testTenant)
+	tenantLimiters.Lock()
+	tl.retryAfter = time.Now().Add(10 * time.Minute)
+	tenantLimiters.Unlock()
+	t.Cleanup(func() {
+		tenantLimiters.Lock()
+		delete(tenantLimiters.mp, testTenant)
+		tenantLimiters.Unlock()
+	})
+	throttled := fmt.Errorf("Too Many Requests (GET) https://%s/api/config/v1/dashboards", testTenant)
+	if got := nextRetryDelay(uid, throttled); got < 9*time.Minute {
+		t.Errorf("delay of a throttled tenant = %s, want the Retry-After of 10m", got)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		val  string
+		want time.Duration
+	}{
+		{val: "120", want: 2 * time.Minute},
+		{val: time.Now().Add(time.Hour).UTC().Format(http.TimeFormat), want: time.Hour},
+		{val: ""},
+		{val: "soon"},
+	}
+	for _, tt := range tests {
+		got := parseRetryAfter(tt.val)
+		if got > tt.want || got < tt.want-2*time.Second {
+			t.Errorf("parseRetryAfter(%q) = %s, want %s", tt.val, got, tt.want)
+		}
+	}
+}
+
+func TestRetryAfterTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	t.Cleanup(server.Close)
+	u, _ := url.Parse(server.URL)
+	client := &http.Client{Transport: &RetryAfterTransport{base: http.DefaultTransport}}
+	t.Cleanup(func() {
+		tenantLimiters.Lock()
+		delete(tenantLimiters.mp, u.Host)
+		tenantLimiters.Unlock()
+	})
+	throttled := fmt.Errorf("Too Many Requests (GET) %s/api/config/v1/dashboards", server.URL)
+
+	// the Retry-After of a host which is not a tenant of the provider is ignored
+	resp, err := client.Get(server.URL + "/api/config/v1/dashboards")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got := getRetryAfter(throttled); got > 0 {
+		t.Errorf("getRetryAfter() of a host which is not a tenant = %s, want 0", got)
+	}
+
+	providerServers.Lock()
+	providerServers.mp["retry-test"] = &pooledServer{hosts: []string{u.Host}}
+	providerServers.Unlock()
+	t.Cleanup(func() {
+		providerServers.Lock()
+		delete(providerServers.mp, "retry-test")
+		providerServers.Unlock()
+	})
+
+	resp, err = client.Get(server.URL + "/api/config/v1/dashboards")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got := getRetryAfter(throttled); got < 110*time.Second || got > 2*time.Minute {
+		t.Errorf("getRetryAfter() = %s, want the Retry-After of 2m", got)
+	}
+}
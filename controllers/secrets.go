@@ -0,0 +1,132 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the AppsCode Community License 1.0.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://github.com/appscode/licenses/raw/1.0.0/AppsCode-Community-1.0.0.md
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+)
+
+// secretRefFields are the fields of the objects referencing a Secret in their namespace: the provider
+// configuration, the sensitive fields of the resource and the configuration of the state backend
+var secretRefFields = [][]string{
+	{"spec", "providerRef", "name"},
+	{"spec", "secretRef", "name"},
+	{"spec", "backendRef", "name"},
+}
+
+func secretRefIndex(field []string) string {
+	index := ""
+	for _, f := range field {
+		index += "." + f
+	}
+	return index
+}
+
+// SetupSecretIndexes indexes the objects of the kind by the names of the Secrets they reference
+func SetupSecretIndexes(ctx context.Context, mgr ctrl.Manager, obj client.Object) error {
+	for _, field := range secretRefFields {
+		field := field
+		err := mgr.GetFieldIndexer().IndexField(ctx, obj, secretRefIndex(field), func(o client.Object) []string {
+			content, ok := o.(*unstructured.Unstructured)
+			if !ok {
+				m, err := runtime.DefaultUnstructuredConverter.ToUnstructured(o)
+				if err != nil {
+					klog.Error(err)
+					return nil
+				}
+				content = &unstructured.Unstructured{Object: m}
+			}
+			name, _, _ := unstructured.NestedString(content.Object, field...)
+			if name == "" {
+				return nil
+			}
+			return []string{name}
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;delete
+
+// EnqueueSecretReferences returns the handler enqueueing the objects of the kind which reference the
+// changed Secret, so that rotated credentials and sensitive values are applied without waiting for a resync
+func EnqueueSecretReferences(mgr ctrl.Manager, gvk schema.GroupVersionKind, obj client.Object, restrictToNamespace string) handler.EventHandler {
+	rClient := mgr.GetClient()
+	listGVK := gvk.GroupVersion().WithKind(gvk.Kind + "List")
+
+	return handler.EnqueueRequestsFromMapFunc(func(secret client.Object) []ctrl.Request {
+		if restrictToNamespace != "" && secret.GetNamespace() != restrictToNamespace {
+			return nil
+		}
+
+		seen := make(map[types.NamespacedName]bool)
+		var requests []ctrl.Request
+
+		for _, field := range secretRefFields {
+			var list client.ObjectList
+			if _, ok := obj.(*unstructured.Unstructured); ok {
+				ul := &unstructured.UnstructuredList{}
+				ul.SetGroupVersionKind(listGVK)
+				list = ul
+			} else {
+				o, err := mgr.GetScheme().New(listGVK)
+				if err != nil {
+					klog.Error(err)
+					return nil
+				}
+				list = o.(client.ObjectList)
+			}
+
+			err := rClient.List(context.TODO(), list, client.InNamespace(secret.GetNamespace()), client.MatchingFields{secretRefIndex(field): secret.GetName()})
+			if err != nil {
+				klog.Errorf("unable to list %s referencing secret %s/%s: %v", gvk.Kind, secret.GetNamespace(), secret.GetName(), err)
+				continue
+			}
+
+			items, err := meta.ExtractList(list)
+			if err != nil {
+				klog.Error(err)
+				continue
+			}
+			for _, item := range items {
+				o, ok := item.(client.Object)
+				if !ok {
+					continue
+				}
+				key := types.NamespacedName{Namespace: o.GetNamespace(), Name: o.GetName()}
+				if !seen[key] {
+					seen[key] = true
+					requests = append(requests, ctrl.Request{NamespacedName: key})
+				}
+			}
+		}
+		return requests
+	})
+}
@@ -0,0 +1,94 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the AppsCode Community License 1.0.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://github.com/appscode/licenses/raw/1.0.0/AppsCode-Community-1.0.0.md
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	errors2 "errors"
+	"fmt"
+	"os"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"kubeform.dev/terraform-backend-sdk/states/remote"
+	"kubeform.dev/terraform-backend-sdk/states/statemgr"
+)
+
+const (
+	ConditionStateLocked = "StateLocked"
+
+	stateLockOperationApply   = "apply"
+	stateLockOperationDestroy = "destroy"
+)
+
+var (
+	// stateLocking enables the locking of the remote state, set by SetupStateLocking
+	stateLocking = true
+	// stateLockTimeout is how long a reconcile waits for the lock of the remote state held by someone else
+	stateLockTimeout = 30 * time.Second
+	// stateLockLeaseDuration is how long a lock of the controller is held at most, an older lock is taken over
+	stateLockLeaseDuration = 10 * time.Minute
+)
+
+// SetupStateLocking configures the locking of the remote state of the objects. The state of a backend which
+// supports locking is locked while it is read, changed and written back, so that another replica or Terraform
+// running against the same state doesn't overwrite the changes. A lock of the controller expires after the
+// lease duration, so that the lock of a replica which crashed while holding it is taken over.
+func SetupStateLocking(enabled bool, timeout, leaseDuration time.Duration) {
+	stateLocking = enabled
+	stateLockTimeout = timeout
+	stateLockLeaseDuration = leaseDuration
+}
+
+// lockRemoteState locks the remote state of the object if the backend supports locking, and returns the
+// function unlocking it. A waitError is returned if the state stays locked by someone else.
+func lockRemoteState(ctx context.Context, remoteClient remote.Client, obj *unstructured.Unstructured) (func() error, error) {
+	locker, ok := remoteClient.(remote.ClientLocker)
+	if !stateLocking || !ok {
+		return func() error { return nil }, nil
+	}
+
+	info := statemgr.NewLockInfo()
+	info.Operation = stateLockOperationApply
+	if obj.GetDeletionTimestamp() != nil {
+		info.Operation = stateLockOperationDestroy
+	}
+	host, _ := os.Hostname()
+	info.Who = "provider-dynatrace-controller@" + host
+	info.Info = fmt.Sprintf("%s %s/%s (uid %s)", obj.GetObjectKind().GroupVersionKind().GroupKind(), obj.GetNamespace(), obj.GetName(), obj.GetUID())
+
+	lockCtx, cancel := context.WithTimeout(ctx, stateLockTimeout)
+	defer cancel()
+	id, err := statemgr.LockWithContext(lockCtx, locker, info)
+	if err != nil {
+		var lerr *statemgr.LockError
+		if errors2.As(err, &lerr) && lerr.Info != nil {
+			return nil, &waitError{
+				condition: ConditionStateLocked,
+				msg:       fmt.Sprintf("the state is locked by %s since %s for %s %s, lock id %s", lerr.Info.Who, lerr.Info.Created.Format(time.RFC3339), lerr.Info.Operation, lerr.Info.Info, lerr.Info.ID),
+			}
+		}
+		return nil, fmt.Errorf("failed to lock the state: %v", err)
+	}
+
+	return func() error {
+		if err := locker.Unlock(id); err != nil {
+			return fmt.Errorf("failed to unlock the state, lock id %s: %v", id, err)
+		}
+		return nil
+	}, nil
+}
@@ -25,13 +25,17 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/fatih/structs"
 	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/go-cty/cty/msgpack"
 	"github.com/hashicorp/go-uuid"
 	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
 	tfschema "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 	"github.com/imdario/mergo"
@@ -43,6 +47,8 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
 	kmapi "kmodules.xyz/client-go/api/v1"
 	"kmodules.xyz/client-go/meta"
 	base "kubeform.dev/apimachinery/api/v1alpha1"
@@ -64,6 +70,7 @@ import (
 	"kubeform.dev/terraform-backend-sdk/states/remote"
 	"kubeform.dev/terraform-backend-sdk/states/statefile"
 	"sigs.k8s.io/cli-utils/pkg/kstatus/status"
+	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -71,6 +78,25 @@ const (
 	KFCFinalizer       = "dynatrace.kubeform.com/finalizer"
 	UnknownIdValue     = "4856ec62-a372-11eb-bcbc-0242ac130002"
 	UpdateNotSupported = "doesn't support update"
+
+	DriftPolicyKey     = "dynatrace.kubeform.com/drift-policy"
+	DriftPolicyEnforce = "Enforce"
+	DriftPolicyReport  = "Report"
+
+	ConditionDrifted = "Drifted"
+	// ConditionDiagnostics holds the diagnostics of the provider for the last failed reconcile
+	ConditionDiagnostics = "Diagnostics"
+	// DiagnosticsKey is the annotation holding the diagnostics of the provider for the last failed reconcile in
+	// json, e.g. [{"severity": "Error", "summary": "Missing required argument", "path": "spec.resource.name"}].
+	// The status of the objects has no field for them.
+	DiagnosticsKey = "dynatrace.kubeform.com/diagnostics"
+
+	// TerminationPolicyKey is the annotation overriding spec.terminationPolicy
+	TerminationPolicyKey = "dynatrace.kubeform.com/termination-policy"
+	// TerminationPolicyOrphan removes the object from the cluster and keeps it in Dynatrace
+	TerminationPolicyOrphan base.TerminationPolicy = "Orphan"
+
+	maxReportedDrifts = 10
 )
 
 type stateVersionV4 struct{}
@@ -126,27 +152,91 @@ type stateV4 struct {
 	Resources        []resourceStateV4        `json:"resources"`
 }
 
-func StartProcess(rClient client.Client, provider *tfschema.Provider, ctx context.Context, res *tfschema.Resource, gv schema.GroupVersion, unstructuredObj *unstructured.Unstructured, tName string, jsonit jsoniter.API) error {
+// FailProcess records an error which stops the reconcile before StartProcess, e.g. a source of spec.resource
+// which can't be translated, like StartProcess records the errors of the reconcile
+func FailProcess(rClient client.Client, recorder record.EventRecorder, ctx context.Context, gv schema.GroupVersion, unstructuredObj *unstructured.Unstructured, err error) (ctrl.Result, error) {
+	if isKindStopped(unstructuredObj.GroupVersionKind()) {
+		return ctrl.Result{}, nil
+	}
+	recordReconcile(unstructuredObj, ResultFailed)
+	recorder.Event(unstructuredObj, corev1.EventTypeWarning, EventReasonFailed, err.Error())
+	err2 := initialUpdateStatus(rClient, ctx, gv, unstructuredObj, err, false)
+	if err2 != nil {
+		return ctrl.Result{}, err2
+	}
+	return ctrl.Result{}, err
+}
+
+// ReconcileOnCreate returns true if an object seen by the informer of the controller is reconciled, including the
+// objects listed when the controller starts. A reconciled object is only requeued after the resync period by the
+// process which reconciled it, so it is reconciled again after a restart to keep refreshing it from Dynatrace.
+func ReconcileOnCreate(obj client.Object, resyncPeriod time.Duration) bool {
+	return resyncPeriod > 0 || !meta.MustAlreadyReconciled(obj)
+}
+
+func StartProcess(rClient client.Client, recorder record.EventRecorder, provider *tfschema.Provider, ctx context.Context, res *tfschema.Resource, gv schema.GroupVersion, unstructuredObj *unstructured.Unstructured, tName string, jsonit jsoniter.API, resyncPeriod time.Duration) (ctrl.Result, error) {
+	if isKindStopped(unstructuredObj.GroupVersionKind()) {
+		return ctrl.Result{}, nil
+	}
+
+	if isDryRun(unstructuredObj) && unstructuredObj.GetDeletionTimestamp() == nil {
+		// only plan the object, nothing is changed in Dynatrace
+		return dryRun(rClient, recorder, provider, ctx, res, gv, unstructuredObj, tName, jsonit, resyncPeriod)
+	}
+
 	err := initialUpdateStatus(rClient, ctx, gv, unstructuredObj, nil, true)
 	if err != nil {
-		return err
+		return ctrl.Result{}, err
 	}
 
-	err = reconcile(rClient, provider, ctx, res, gv, unstructuredObj, tName, jsonit)
+	err = reconcile(rClient, recorder, provider, ctx, res, gv, unstructuredObj, tName, jsonit)
+	if err == nil && unstructuredObj.GetDeletionTimestamp() == nil {
+		err = publishOutputs(rClient, ctx, gv, res, unstructuredObj, jsonit)
+	}
+	var werr *waitError
+	if errors2.As(err, &werr) {
+		// the object depends on other objects, check again later instead of failing
+		err2 := waitUpdateStatus(rClient, ctx, gv, unstructuredObj, werr)
+		if err2 != nil {
+			return ctrl.Result{}, err2
+		}
+		return ctrl.Result{RequeueAfter: waitRequeueInterval}, nil
+	}
+	if isTransientError(err) {
+		recordReconcile(unstructuredObj, ResultFailed)
+		// the tenant is throttling the requests or unavailable, try again later instead of failing
+		delay := nextRetryDelay(unstructuredObj.GetUID(), err)
+		recorder.Eventf(unstructuredObj, corev1.EventTypeWarning, EventReasonRetrying, "%s, retrying in %s", err, delay.Round(time.Second))
+		err2 := waitUpdateStatus(rClient, ctx, gv, unstructuredObj, &waitError{
+			condition: ConditionRetrying,
+			msg:       fmt.Sprintf("%s, retrying in %s", err, delay.Round(time.Second)),
+		})
+		if err2 != nil {
+			return ctrl.Result{}, err2
+		}
+		return ctrl.Result{RequeueAfter: delay}, nil
+	}
+	resetRetryDelay(unstructuredObj.GetUID())
 	if err != nil {
+		recordReconcile(unstructuredObj, ResultFailed)
+		recorder.Event(unstructuredObj, corev1.EventTypeWarning, EventReasonFailed, err.Error())
 		err2 := initialUpdateStatus(rClient, ctx, gv, unstructuredObj, err, false)
 		if err2 != nil {
-			return err2
+			return ctrl.Result{}, err2
 		}
-		return err
+		return ctrl.Result{}, err
 	}
 
-	return finalUpdateStatus(rClient, ctx, gv, unstructuredObj)
-}
+	err = finalUpdateStatus(rClient, ctx, gv, unstructuredObj)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
 
-func reconcile(rClient client.Client, provider *tfschema.Provider, ctx context.Context, res *tfschema.Resource, gv schema.GroupVersion, unstructuredObj *unstructured.Unstructured, tName string, jsonit jsoniter.API) error {
-	server := tfschema.NewGRPCProviderServer(provider)
+	// requeue the object so that changes made directly in Dynatrace are detected
+	return ctrl.Result{RequeueAfter: resyncPeriod}, nil
+}
 
+func reconcile(rClient client.Client, recorder record.EventRecorder, provider *tfschema.Provider, ctx context.Context, res *tfschema.Resource, gv schema.GroupVersion, unstructuredObj *unstructured.Unstructured, tName string, jsonit jsoniter.API) (err error) {
 	// Get RawSpec (including sensitive data)
 	rawSpec, err := getSpecWithSensitiveData(gv, rClient, ctx, unstructuredObj, jsonit)
 	if err != nil {
@@ -174,6 +264,20 @@ func reconcile(rClient client.Client, provider *tfschema.Provider, ctx context.C
 			return err
 		}
 
+		// the state is locked until it is written back
+		unlock, lockErr := lockRemoteState(ctx, remoteClient, unstructuredObj)
+		if lockErr != nil {
+			return lockErr
+		}
+		defer func() {
+			if err2 := unlock(); err2 != nil {
+				klog.Error(err2)
+				if err == nil {
+					err = err2
+				}
+			}
+		}()
+
 		payloadData, err := getRemoteState(remoteClient)
 		if err != nil {
 			return err
@@ -257,50 +361,73 @@ func reconcile(rClient client.Client, provider *tfschema.Provider, ctx context.C
 		return err
 	}
 
-	// Set ProviderMeta
-	err = setProviderMeta(rClient, provider, ctx, unstructuredObj, server, jsonit)
+	// Get the provider server configured for the object
+	server, token, err := getProviderServer(rClient, provider, ctx, unstructuredObj)
 	if err != nil {
 		return err
 	}
-
-	// validation check
-	if rawSpec["id"] == nil {
-		rawSpec["id"] = UnknownIdValue
-	}
-	rawSpecCty := HCL2ValueFromConfigValue(rawSpec)
-	initialState, err := msgpack.Marshal(rawSpecCty, res.CoreConfigSchema().ImpliedType())
+	defer func() {
+		if isAuthError(err) && forgetRejectedServer(server) {
+			// the primary API token was revoked since the server was configured, retry with the secondary one
+			err = &waitError{
+				condition: ConditionRetrying,
+				msg:       fmt.Sprintf("the primary API token was rejected, retrying with the secondary API token: %s", err),
+			}
+		}
+	}()
+	err = setAPITokenCondition(rClient, recorder, ctx, gv, unstructuredObj, token)
 	if err != nil {
 		return err
 	}
-	req := &tfprotov5.ValidateResourceTypeConfigRequest{
-		TypeName: tName,
-		Config: &tfprotov5.DynamicValue{
-			MsgPack: initialState,
-		},
-	}
-	valid, err := server.ValidateResourceTypeConfig(ctx, req)
+	defaults, err := getProviderDefaults(rClient, ctx, unstructuredObj)
 	if err != nil {
 		return err
 	}
-	if len(valid.Diagnostics) > 0 {
-		err = diagToError(valid.Diagnostics)
-		if err != nil {
-			return err
-		}
+
+	// validation check
+	err = validateResourceConfig(ctx, server, res, tName, rawSpec)
+	if err != nil {
+		return err
 	}
 
 	if hasFinalizer(unstructuredObj.GetFinalizers(), KFCFinalizer) {
 		if unstructuredObj.GetDeletionTimestamp() != nil {
-			err := updateStatus(rClient, ctx, unstructuredObj, status.TerminatingStatus)
+			terminationPolicy, err := getTerminationPolicy(unstructuredObj, defaults)
+			if err != nil {
+				// an unknown policy must not destroy the object in Dynatrace
+				return &waitError{
+					condition: ConditionDeletionBlocked,
+					msg:       fmt.Sprintf("%s can't be terminated: %v", unstructuredObj.GetName(), err),
+				}
+			}
+			if terminationPolicy == base.TerminationPolicyDoNotTerminate {
+				return &waitError{
+					condition: ConditionDeletionBlocked,
+					msg:       fmt.Sprintf("%s can't be terminated. To delete, set spec.terminationPolicy to %s and the %s annotation, if any, to %s or %s", unstructuredObj.GetName(), base.TerminationPolicyDelete, TerminationPolicyKey, base.TerminationPolicyDelete, TerminationPolicyOrphan),
+				}
+			}
+			if terminationPolicy != TerminationPolicyOrphan {
+				// objects referenced by other objects are kept until the references are removed
+				err := checkDependents(rClient, ctx, unstructuredObj)
+				if err != nil {
+					return err
+				}
+			}
+			err = updateStatus(rClient, ctx, unstructuredObj, status.TerminatingStatus)
 			if err != nil {
 				return err
 			}
 			// if not found then also delete
-			if found {
+			// orphaned objects are kept in Dynatrace
+			result := ResultOrphaned
+			if found && terminationPolicy != TerminationPolicyOrphan {
+				start := time.Now()
 				err = destroyTheObject(rawStatus, res, server, tName)
-				if err != nil && !strings.Contains(err.Error(), "[404] Not found") {
+				observeOperation(unstructuredObj, OperationDestroy, start)
+				if err != nil && !isNotFoundError(err) {
 					return err
 				}
+				result = ResultDestroyed
 			}
 			if backendfound {
 				// delete the remote state
@@ -327,7 +454,14 @@ func reconcile(rClient client.Client, provider *tfschema.Provider, ctx context.C
 					return err
 				}
 			}
-			return removeFinalizer(ctx, rClient, unstructuredObj, KFCFinalizer)
+			err = removeFinalizer(ctx, rClient, unstructuredObj, KFCFinalizer)
+			if err != nil {
+				return err
+			}
+			recordReconcile(unstructuredObj, result)
+			publishResult(recorder, unstructuredObj, result)
+			ForgetObjectMetrics(unstructuredObj.GroupVersionKind(), types.NamespacedName{Namespace: unstructuredObj.GetNamespace(), Name: unstructuredObj.GetName()})
+			return nil
 		}
 	} else {
 		err := addFinalizer(ctx, rClient, unstructuredObj, KFCFinalizer)
@@ -336,12 +470,87 @@ func reconcile(rClient client.Client, provider *tfschema.Provider, ctx context.C
 		}
 	}
 
+	if getDriftPolicy(unstructuredObj, defaults) == DriftPolicyReport {
+		err = reportDrift(rClient, recorder, ctx, gv, unstructuredObj, rawSpec, rawStatus, found, res, server, tName)
+		if err != nil {
+			return err
+		}
+		recordReconcile(unstructuredObj, ResultNoop)
+		return nil
+	}
+
+	if found && rawStatus["id"] == nil {
+		// adopt the existing object: import it using the id of spec.resource and seed the state
+		importedState, exists, err := importTheObject(fmt.Sprint(rawSpec["id"]), res, server, tName)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("%s with id %v doesn't exist in Dynatrace, remove spec.resource.id to create a new one", tName, rawSpec["id"])
+		}
+
+		if backendfound {
+			err = storeRemoteState(tName, payLoad, remoteClient, importedState, gv, unstructuredObj, jsonit)
+			if err != nil {
+				return err
+			}
+		} else {
+			err = updateStateField(rClient, ctx, importedState, gv, unstructuredObj, jsonit)
+			if err != nil {
+				return err
+			}
+		}
+		rawStatus = importedState
+		klog.Infof("%s %s/%s adopted existing object %v", unstructuredObj.GetKind(), unstructuredObj.GetNamespace(), unstructuredObj.GetName(), rawSpec["id"])
+	} else if found {
+		// refresh the state from the live object to detect changes made outside of Kubeform
+		liveState, exists, err := readTheObject(rawStatus, res, server, tName)
+		if err != nil {
+			return err
+		}
+
+		if !exists {
+			klog.Infof("%s %s/%s no longer exists in Dynatrace, it will be recreated", unstructuredObj.GetKind(), unstructuredObj.GetNamespace(), unstructuredObj.GetName())
+			found = false
+		} else {
+			drift, err := getDrift(rawStatus, liveState, res)
+			if err != nil {
+				return err
+			}
+
+			if len(drift) > 0 {
+				var attrs []string
+				for _, d := range drift {
+					attrs = append(attrs, d.Path)
+				}
+				klog.Infof("%s %s/%s has drifted from the last applied state: %s", unstructuredObj.GetKind(), unstructuredObj.GetNamespace(), unstructuredObj.GetName(), strings.Join(attrs, ", "))
+				recordDrift(unstructuredObj)
+				recorder.Eventf(unstructuredObj, corev1.EventTypeWarning, EventReasonDriftDetected, "%s has drifted from the last applied state, reverting %s", unstructuredObj.GetKind(), strings.Join(attrs, ", "))
+
+				if backendfound {
+					err = storeRemoteState(tName, payLoad, remoteClient, liveState, gv, unstructuredObj, jsonit)
+					if err != nil {
+						return err
+					}
+				} else {
+					err = updateStateField(rClient, ctx, liveState, gv, unstructuredObj, jsonit)
+					if err != nil {
+						return err
+					}
+				}
+				rawStatus = liveState
+			}
+		}
+	}
+
 	if !found {
 		err := updateStatus(rClient, ctx, unstructuredObj, status.InProgressStatus)
 		if err != nil {
 			return err
 		}
+		start := time.Now()
 		newStateVal, intrfc, err := createTheObject(rawSpec, res, server, tName)
+		observeOperation(unstructuredObj, OperationCreate, start)
 		if err != nil {
 			return err
 		}
@@ -372,6 +581,8 @@ func reconcile(rClient client.Client, provider *tfschema.Provider, ctx context.C
 		if err = rClient.Update(ctx, unstructuredObj); err != nil {
 			return err
 		}
+		recordReconcile(unstructuredObj, ResultCreated)
+		publishResult(recorder, unstructuredObj, ResultCreated)
 		return nil
 	}
 
@@ -385,6 +596,7 @@ func reconcile(rClient client.Client, provider *tfschema.Provider, ctx context.C
 		return err
 	}
 	if !changed {
+		recordReconcile(unstructuredObj, ResultNoop)
 		return nil
 	}
 
@@ -406,7 +618,9 @@ func reconcile(rClient client.Client, provider *tfschema.Provider, ctx context.C
 		if err != nil {
 			return err
 		}
+		start := time.Now()
 		err = destroyTheObject(rawStatus, res, server, tName)
+		observeOperation(unstructuredObj, OperationDestroy, start)
 		if err != nil {
 			return err
 		}
@@ -415,7 +629,9 @@ func reconcile(rClient client.Client, provider *tfschema.Provider, ctx context.C
 		if err != nil {
 			return err
 		}
+		start = time.Now()
 		newStateVal, intrfc, err := createTheObject(rawSpec, res, server, tName)
+		observeOperation(unstructuredObj, OperationCreate, start)
 		if err != nil {
 			return err
 		}
@@ -446,10 +662,14 @@ func reconcile(rClient client.Client, provider *tfschema.Provider, ctx context.C
 		if err = rClient.Update(ctx, unstructuredObj); err != nil {
 			return err
 		}
+		recordReconcile(unstructuredObj, ResultReplaced)
+		publishResult(recorder, unstructuredObj, ResultReplaced)
 		return nil
 	}
 
+	start := time.Now()
 	newStateVal, updated, err := updateTheObject(priorState, plannedState, planResp, server, res, tName)
+	observeOperation(unstructuredObj, OperationUpdate, start)
 	if err != nil {
 		return err
 	}
@@ -479,6 +699,10 @@ func reconcile(rClient client.Client, provider *tfschema.Provider, ctx context.C
 				return err
 			}
 		}
+		recordReconcile(unstructuredObj, ResultUpdated)
+		publishResult(recorder, unstructuredObj, ResultUpdated)
+	} else {
+		recordReconcile(unstructuredObj, ResultNoop)
 	}
 
 	return nil
@@ -636,6 +860,10 @@ func getRemoteClient(backendRef string, rClient client.Client, ctx context.Conte
 		break
 	}
 
+	if backendName == KubernetesBackend {
+		return newKubernetesClient(rClient, ctx, obj.GetNamespace(), byt)
+	}
+
 	tempBObj := make(map[string]interface{})
 
 	err := jsonit.Unmarshal(byt, &tempBObj)
@@ -694,28 +922,32 @@ func initialUpdateStatus(rClient client.Client, ctx context.Context, gv schema.G
 		return err
 	}
 
-	data, err := meta.MarshalToJson(obj, gv)
+	conditions, err := getConditions(gv, obj)
 	if err != nil {
 		return err
 	}
 
-	typedObj, err := meta.UnmarshalFromJSON(data, gv)
-	if err != nil {
-		return err
-	}
-
-	typedStruct := structs.New(typedObj)
-	conditionsVal := reflect.ValueOf(typedStruct.Field("Status").Field("Conditions").Value())
-	conditions := conditionsVal.Interface().([]kmapi.Condition)
-	if kmapi.HasCondition(conditions, "Stalled") {
-		return nil
-	}
-
+	// Stalled and the diagnostics are kept until the next reconcile either fails or succeeds
 	phase := status.InProgressStatus
 	if flag {
 		conditions = kmapi.SetCondition(conditions, kmapi.NewCondition("Reconciling", "Kubeform is currently reconciling "+obj.GetKind()+" resource", objGen))
 	} else {
 		conditions = kmapi.SetCondition(conditions, kmapi.NewCondition("Stalled", er.Error(), objGen))
+		conditions = removeDiagnosticConditions(conditions)
+
+		resType, err := getResourceType(gv, obj)
+		if err != nil {
+			return err
+		}
+		diags := getDiagnostics(er, func(steps []string) string {
+			return resourceFieldPath(resType, steps)
+		})
+		if len(diags) > 0 {
+			conditions = kmapi.SetCondition(conditions, diagnosticsCondition(diags, objGen))
+		}
+		if err = setDiagnostics(rClient, ctx, obj, diags); err != nil {
+			return err
+		}
 		phase = status.FailedStatus
 	}
 
@@ -731,8 +963,32 @@ func initialUpdateStatus(rClient client.Client, ctx context.Context, gv schema.G
 }
 
 func finalUpdateStatus(rClient client.Client, ctx context.Context, gv schema.GroupVersion, obj *unstructured.Unstructured) error {
+	defaults, err := getProviderDefaults(rClient, ctx, obj)
+	if err != nil {
+		return err
+	}
+
+	conditions, err := getConditions(gv, obj)
+	if err != nil {
+		return err
+	}
+
+	if err = setDiagnostics(rClient, ctx, obj, nil); err != nil {
+		return err
+	}
+
 	var newCondi []kmapi.Condition
-	err := setNestedFieldNoCopy(obj.Object, newCondi, "status", "conditions")
+	if getDriftPolicy(obj, defaults) == DriftPolicyReport {
+		// drift is only reported, so the Drifted condition is kept until the live object matches the spec
+		if _, cond := kmapi.GetCondition(conditions, ConditionDrifted); cond != nil {
+			newCondi = append(newCondi, *cond)
+		}
+	}
+	// the API token in use is kept until the provider configuration changes
+	if _, cond := kmapi.GetCondition(conditions, ConditionAPIToken); cond != nil {
+		newCondi = append(newCondi, *cond)
+	}
+	err = setNestedFieldNoCopy(obj.Object, newCondi, "status", "conditions")
 	if err != nil {
 		return err
 	}
@@ -767,6 +1023,9 @@ func updateStatus(rClient client.Client, ctx context.Context, obj *unstructured.
 	if err = rClient.Status().Update(ctx, obj); err != nil {
 		return err
 	}
+	if obj.GetDeletionTimestamp() == nil || hasFinalizer(obj.GetFinalizers(), KFCFinalizer) {
+		setPhaseMetric(obj, phase)
+	}
 	return nil
 }
 
@@ -794,20 +1053,176 @@ func jsonPath(fields []string) string {
 	return "." + strings.Join(fields, ".")
 }
 
+// diagnosticsError holds the diagnostics returned by the provider for a failed request
+type diagnosticsError struct {
+	diags []*tfprotov5.Diagnostic
+}
+
+func (e *diagnosticsError) Error() string {
+	var msgs []string
+	for _, d := range e.diags {
+		if d.Severity == tfprotov5.DiagnosticSeverityWarning {
+			continue
+		}
+		msg := d.Summary
+		if d.Detail != "" {
+			msg += ": " + d.Detail
+		}
+		if steps := attributePathSteps(d.Attribute); len(steps) > 0 {
+			msg = strings.Join(steps, ".") + ": " + msg
+		}
+		msgs = append(msgs, msg)
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// diagnostic is a diagnostic of the provider as it is published in the status of the object
+type diagnostic struct {
+	Severity string `json:"severity"`
+	Summary  string `json:"summary"`
+	Detail   string `json:"detail,omitempty"`
+	Path     string `json:"path,omitempty"`
+}
+
 func diagToError(d []*tfprotov5.Diagnostic) error {
-	var err error
-	var flag bool
-	for idx, key := range d {
-		if key.Severity.String() == "WARNING" || key.Summary == "Invalid or unknown key" || key.Summary == UpdateNotSupported {
+	var diags []*tfprotov5.Diagnostic
+	var hasError bool
+	for _, key := range d {
+		if key.Summary == "Invalid or unknown key" || key.Summary == UpdateNotSupported {
 			continue
 		}
-		if !flag {
-			err = errors2.New("")
-			flag = true
+		if key.Severity != tfprotov5.DiagnosticSeverityWarning {
+			hasError = true
 		}
-		err = errors2.Wrapf(err, "%s %d: %s", "Error", idx, key.Summary)
+		diags = append(diags, key)
 	}
-	return err
+	if !hasError {
+		return nil
+	}
+	return &diagnosticsError{diags: diags}
+}
+
+// attributePathSteps returns the steps of the attribute path in the form used by the flatmap of the state
+func attributePathSteps(path *tftypes.AttributePath) []string {
+	if path == nil {
+		return nil
+	}
+
+	var steps []string
+	for _, step := range path.Steps() {
+		switch s := step.(type) {
+		case tftypes.AttributeName:
+			steps = append(steps, string(s))
+		case tftypes.ElementKeyString:
+			steps = append(steps, string(s))
+		case tftypes.ElementKeyInt:
+			steps = append(steps, strconv.FormatInt(int64(s), 10))
+		default:
+			// elements of sets have no stable index
+			steps = append(steps, "*")
+		}
+	}
+	return steps
+}
+
+// getDiagnostics returns the diagnostics of err with the attribute paths converted into the paths of the
+// fields in the object
+func getDiagnostics(err error, fieldPath func(steps []string) string) []diagnostic {
+	var derr *diagnosticsError
+	if !errors2.As(err, &derr) {
+		return nil
+	}
+
+	var diags []diagnostic
+	for _, d := range derr.diags {
+		diag := diagnostic{
+			Severity: "Error",
+			Summary:  d.Summary,
+			Detail:   d.Detail,
+		}
+		if d.Severity == tfprotov5.DiagnosticSeverityWarning {
+			diag.Severity = "Warning"
+		}
+		if steps := attributePathSteps(d.Attribute); len(steps) > 0 {
+			diag.Path = fieldPath(steps)
+		}
+		diags = append(diags, diag)
+	}
+	return diags
+}
+
+// diagnosticsCondition returns the Diagnostics condition listing the diagnostics, one per line. The reason
+// is the highest severity of the diagnostics.
+func diagnosticsCondition(diags []diagnostic, objGen int64) kmapi.Condition {
+	reason := "Warning"
+	msgs := make([]string, 0, len(diags))
+	for _, d := range diags {
+		if d.Severity == "Error" {
+			reason = "Error"
+		}
+		msg := d.Severity + ": "
+		if d.Path != "" {
+			msg += d.Path + ": "
+		}
+		msg += d.Summary
+		if d.Detail != "" {
+			msg += ": " + d.Detail
+		}
+		msgs = append(msgs, msg)
+	}
+
+	cond := kmapi.NewCondition(ConditionDiagnostics, strings.Join(msgs, "\n"), objGen)
+	cond.Reason = reason
+	return cond
+}
+
+// setDiagnostics patches the annotation holding the diagnostics, it is removed if there are none
+func setDiagnostics(rClient client.Client, ctx context.Context, obj *unstructured.Unstructured, diags []diagnostic) error {
+	annotations := obj.GetAnnotations()
+	val := ""
+	if len(diags) > 0 {
+		data, err := json.Marshal(diags)
+		if err != nil {
+			return err
+		}
+		val = string(data)
+	}
+	if current, ok := annotations[DiagnosticsKey]; current == val && (ok || val == "") {
+		return nil
+	}
+
+	// only the metadata is patched, the changes of the caller to the rest of the object are kept
+	latest := obj.DeepCopy()
+	patch := client.MergeFrom(obj.DeepCopy())
+	if val == "" {
+		delete(annotations, DiagnosticsKey)
+	} else {
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		annotations[DiagnosticsKey] = val
+	}
+	latest.SetAnnotations(annotations)
+
+	err := rClient.Patch(ctx, latest, patch)
+	if err != nil {
+		return err
+	}
+	obj.SetAnnotations(latest.GetAnnotations())
+	obj.SetResourceVersion(latest.GetResourceVersion())
+	return nil
+}
+
+// removeDiagnosticConditions removes the diagnostics of a previous reconcile, including the numbered
+// Diagnostic conditions published by earlier versions
+func removeDiagnosticConditions(conditions []kmapi.Condition) []kmapi.Condition {
+	var result []kmapi.Condition
+	for _, cond := range conditions {
+		if !strings.HasPrefix(cond.Type, "Diagnostic") {
+			result = append(result, cond)
+		}
+	}
+	return result
 }
 
 func getCombineRawAndDeepCopyRawStatus(rawStatus map[string]interface{}, rawSpec map[string]interface{}) (map[string]interface{}, error) {
@@ -846,6 +1261,9 @@ func updateTheObject(priorState []byte, plannedState []byte, planResp *tfprotov5
 		PlannedPrivate: planResp.PlannedPrivate,
 	}
 
+	if err := waitForTenant(context.Background(), server); err != nil {
+		return cty.Value{}, false, err
+	}
 	applyResp, err := server.ApplyResourceChange(context.Background(), applyReq)
 	if err != nil {
 		return cty.Value{}, false, err
@@ -975,6 +1393,9 @@ func createTheObject(rawSpec map[string]interface{}, res *tfschema.Resource, ser
 		PlannedPrivate: planResp.PlannedPrivate,
 	}
 
+	if err := waitForTenant(context.Background(), server); err != nil {
+		return cty.Value{}, nil, err
+	}
 	applyResp, err := server.ApplyResourceChange(context.Background(), applyReq)
 	if err != nil {
 		return cty.Value{}, nil, err
@@ -992,6 +1413,358 @@ func createTheObject(rawSpec map[string]interface{}, res *tfschema.Resource, ser
 	return newStateVal, intrfc, nil
 }
 
+func readTheObject(rawStatus map[string]interface{}, res *tfschema.Resource, server *tfschema.GRPCProviderServer, tName string) (map[string]interface{}, bool, error) {
+	stateVal := HCL2ValueFromConfigValue(rawStatus)
+	schma := res.CoreConfigSchema()
+	currentState, err := msgpack.Marshal(stateVal, schma.ImpliedType())
+	if err != nil {
+		return nil, false, err
+	}
+
+	return refreshTheObject(currentState, nil, res, server, tName)
+}
+
+// ImportObject reads the existing object with the given id and returns its state keyed by the
+// terraform attribute names. It returns false if the object doesn't exist.
+func ImportObject(id string, res *tfschema.Resource, server *tfschema.GRPCProviderServer, tName string) (map[string]interface{}, bool, error) {
+	return importTheObject(id, res, server, tName)
+}
+
+func importTheObject(id string, res *tfschema.Resource, server *tfschema.GRPCProviderServer, tName string) (map[string]interface{}, bool, error) {
+	importReq := &tfprotov5.ImportResourceStateRequest{
+		TypeName: tName,
+		ID:       id,
+	}
+
+	if err := waitForTenant(context.Background(), server); err != nil {
+		return nil, false, err
+	}
+	importResp, err := server.ImportResourceState(context.Background(), importReq)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(importResp.Diagnostics) > 0 {
+		err = diagToError(importResp.Diagnostics)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	for _, imported := range importResp.ImportedResources {
+		if imported.TypeName != tName {
+			continue
+		}
+		// the imported state usually contains only the id, so read the rest of the object
+		return refreshTheObject(imported.State.MsgPack, imported.Private, res, server, tName)
+	}
+
+	return nil, false, fmt.Errorf("%s with id %s can't be imported", tName, id)
+}
+
+func refreshTheObject(currentState []byte, private []byte, res *tfschema.Resource, server *tfschema.GRPCProviderServer, tName string) (map[string]interface{}, bool, error) {
+	schma := res.CoreConfigSchema()
+	readReq := &tfprotov5.ReadResourceRequest{
+		TypeName: tName,
+		CurrentState: &tfprotov5.DynamicValue{
+			MsgPack: currentState,
+		},
+		Private: private,
+	}
+
+	if err := waitForTenant(context.Background(), server); err != nil {
+		return nil, false, err
+	}
+	readResp, err := server.ReadResource(context.Background(), readReq)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(readResp.Diagnostics) > 0 {
+		err = diagToError(readResp.Diagnostics)
+		if err != nil {
+			if isNotFoundError(err) {
+				return nil, false, nil
+			}
+			return nil, false, err
+		}
+	}
+
+	newStateVal, err := msgpack.Unmarshal(readResp.NewState.MsgPack, schma.ImpliedType())
+	if err != nil {
+		return nil, false, err
+	}
+	// a null state means the object has been deleted outside of Kubeform
+	if newStateVal.IsNull() {
+		return nil, false, nil
+	}
+	intrfc := terraform.NewResourceConfigShimmed(newStateVal, schma)
+
+	return intrfc.Raw, true, nil
+}
+
+type attributeDrift struct {
+	Path      string // attribute path in terraform flatmap notation, e.g. tile.0.name
+	Old       string
+	New       string
+	Sensitive bool
+}
+
+// getDrift returns the attributes whose values differ between the given states, sorted by path.
+func getDrift(oldState map[string]interface{}, newState map[string]interface{}, res *tfschema.Resource) ([]attributeDrift, error) {
+	oldVal := HCL2ValueFromConfigValue(oldState)
+	newVal := HCL2ValueFromConfigValue(newState)
+
+	diff, err := tfschema.DiffFromValues(context.TODO(), oldVal, newVal, cty.NilVal, stripResourceModifiers(res))
+	if err != nil {
+		return nil, err
+	}
+	if diff == nil {
+		return nil, nil
+	}
+
+	var drift []attributeDrift
+	for key, attr := range diff.Attributes {
+		// skip the length attributes of lists, sets and maps
+		if strings.HasSuffix(key, ".#") || strings.HasSuffix(key, ".%") {
+			continue
+		}
+		drift = append(drift, attributeDrift{
+			Path:      key,
+			Old:       attr.Old,
+			New:       attr.New,
+			Sensitive: attr.Sensitive,
+		})
+	}
+	sort.Slice(drift, func(i, j int) bool {
+		return drift[i].Path < drift[j].Path
+	})
+
+	return drift, nil
+}
+
+// terminationPolicyStrictness orders the termination policies, the stricter policy wins when the annotation
+// and spec.terminationPolicy differ
+var terminationPolicyStrictness = map[base.TerminationPolicy]int{
+	base.TerminationPolicyDelete:         0,
+	TerminationPolicyOrphan:              1,
+	base.TerminationPolicyDoNotTerminate: 2,
+}
+
+// ValidateTerminationPolicy returns an error if the policy is not one of Delete, DoNotTerminate and Orphan
+func ValidateTerminationPolicy(policy base.TerminationPolicy) error {
+	if _, ok := terminationPolicyStrictness[policy]; !ok {
+		return fmt.Errorf("unknown termination policy %q, must be one of %s, %s, %s", policy, base.TerminationPolicyDelete, base.TerminationPolicyDoNotTerminate, TerminationPolicyOrphan)
+	}
+	return nil
+}
+
+// getTerminationPolicy returns the stricter of the annotation and spec.terminationPolicy, or the default of the
+// provider configuration if neither is set
+func getTerminationPolicy(obj *unstructured.Unstructured, defaults *ProviderDefaults) (base.TerminationPolicy, error) {
+	var policies []base.TerminationPolicy
+	if policy, ok := obj.GetAnnotations()[TerminationPolicyKey]; ok {
+		policies = append(policies, base.TerminationPolicy(policy))
+	}
+	policy, _, _ := unstructured.NestedString(obj.Object, "spec", "terminationPolicy")
+	if policy != "" {
+		policies = append(policies, base.TerminationPolicy(policy))
+	}
+	if len(policies) == 0 && defaults.TerminationPolicy != "" {
+		policies = append(policies, defaults.TerminationPolicy)
+	}
+
+	result := base.TerminationPolicyDelete
+	for _, p := range policies {
+		if err := ValidateTerminationPolicy(p); err != nil {
+			return "", err
+		}
+		if terminationPolicyStrictness[p] > terminationPolicyStrictness[result] {
+			result = p
+		}
+	}
+	return result, nil
+}
+
+func getDriftPolicy(obj *unstructured.Unstructured, defaults *ProviderDefaults) string {
+	policy, ok := obj.GetAnnotations()[DriftPolicyKey]
+	if !ok {
+		policy = defaults.DriftPolicy
+	}
+	if policy == DriftPolicyReport {
+		return DriftPolicyReport
+	}
+	return DriftPolicyEnforce
+}
+
+// reportDrift compares the live object with spec.resource and publishes the result as the Drifted
+// condition, without changing anything in Dynatrace.
+func reportDrift(rClient client.Client, recorder record.EventRecorder, ctx context.Context, gv schema.GroupVersion, obj *unstructured.Unstructured, rawSpec map[string]interface{}, rawStatus map[string]interface{}, found bool, res *tfschema.Resource, server *tfschema.GRPCProviderServer, tName string) error {
+	drifted := true
+	var message string
+
+	if !found {
+		message = "The object doesn't exist in Dynatrace"
+	} else {
+		var liveState map[string]interface{}
+		var exists bool
+		var err error
+		if rawStatus["id"] == nil {
+			// no state is stored yet, so import the object using the id of spec.resource
+			liveState, exists, err = importTheObject(fmt.Sprint(rawSpec["id"]), res, server, tName)
+		} else {
+			liveState, exists, err = readTheObject(rawStatus, res, server, tName)
+		}
+		if err != nil {
+			return err
+		}
+
+		if !exists {
+			message = fmt.Sprintf("The object with id %v doesn't exist in Dynatrace", rawSpec["id"])
+		} else {
+			combineRaw, err := getCombineRawAndDeepCopyRawStatus(liveState, rawSpec)
+			if err != nil {
+				return err
+			}
+
+			drift, err := getDrift(liveState, combineRaw, res)
+			if err != nil {
+				return err
+			}
+
+			if len(drift) == 0 {
+				drifted = false
+				message = "The live object matches spec.resource"
+			} else {
+				resType, err := getResourceType(gv, obj)
+				if err != nil {
+					return err
+				}
+				message = formatDrift(drift, resType)
+			}
+		}
+	}
+
+	conditions, err := getConditions(gv, obj)
+	if err != nil {
+		return err
+	}
+
+	if drifted {
+		recordDrift(obj)
+		// the drift is kept while it is reported, so only the first detection is published
+		if !kmapi.IsConditionTrue(conditions, ConditionDrifted) {
+			recorder.Event(obj, corev1.EventTypeWarning, EventReasonDriftDetected, message)
+		}
+	}
+	conditions = kmapi.SetCondition(conditions, kmapi.NewCondition(ConditionDrifted, message, obj.GetGeneration(), drifted))
+
+	err = setNestedFieldNoCopy(obj.Object, conditions, "status", "conditions")
+	if err != nil {
+		return err
+	}
+	return rClient.Status().Update(ctx, obj)
+}
+
+// formatDrift renders the drifted attributes as a compact, human readable message using the
+// json paths of the corresponding fields in the object. Sensitive values are never included.
+func formatDrift(drift []attributeDrift, resType reflect.Type) string {
+	var entries []string
+	for i, d := range drift {
+		if i == maxReportedDrifts {
+			entries = append(entries, fmt.Sprintf("and %d more", len(drift)-maxReportedDrifts))
+			break
+		}
+
+		path := resourceFieldPath(resType, strings.Split(d.Path, "."))
+		if d.Sensitive {
+			entries = append(entries, path+": (sensitive value)")
+		} else {
+			entries = append(entries, fmt.Sprintf("%s: %q (live) => %q (spec)", path, d.Old, d.New))
+		}
+	}
+	return fmt.Sprintf("%d field(s) of the live object differ from spec.resource: %s", len(drift), strings.Join(entries, "; "))
+}
+
+// resourceFieldPath maps a terraform attribute path, e.g. [event_type_filters 0 custom_event_filter],
+// to the json path of the field in the object, e.g. spec.resource.eventTypeFilters[0].customEventFilter
+func resourceFieldPath(t reflect.Type, steps []string) string {
+	path := "spec.resource"
+	for _, step := range steps {
+		for t != nil && t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t == nil {
+			path += "." + step
+			continue
+		}
+
+		switch t.Kind() {
+		case reflect.Struct:
+			if _, err := strconv.Atoi(step); err == nil {
+				// blocks with at most one element are represented as a single object in the CRD
+				continue
+			}
+			name := step
+			var fieldType reflect.Type
+			for i := 0; i < t.NumField(); i++ {
+				field := t.Field(i)
+				if strings.Split(field.Tag.Get("tf"), ",")[0] == step {
+					name = strings.Split(field.Tag.Get("json"), ",")[0]
+					fieldType = field.Type
+					break
+				}
+			}
+			path += "." + name
+			t = fieldType
+		case reflect.Slice, reflect.Array:
+			path += "[" + step + "]"
+			t = t.Elem()
+		case reflect.Map:
+			path += "[" + step + "]"
+			t = t.Elem()
+		default:
+			path += "." + step
+			t = nil
+		}
+	}
+	return path
+}
+
+func getResourceType(gv schema.GroupVersion, obj *unstructured.Unstructured) (reflect.Type, error) {
+	data, err := meta.MarshalToJson(obj, gv)
+	if err != nil {
+		return nil, err
+	}
+
+	typedObj, err := meta.UnmarshalFromJSON(data, gv)
+	if err != nil {
+		return nil, err
+	}
+
+	typedStruct := structs.New(typedObj)
+	return reflect.TypeOf(typedStruct.Field("Spec").Field("Resource").Value()), nil
+}
+
+func getConditions(gv schema.GroupVersion, obj *unstructured.Unstructured) ([]kmapi.Condition, error) {
+	data, err := meta.MarshalToJson(obj, gv)
+	if err != nil {
+		return nil, err
+	}
+
+	typedObj, err := meta.UnmarshalFromJSON(data, gv)
+	if err != nil {
+		return nil, err
+	}
+
+	typedStruct := structs.New(typedObj)
+	conditionsVal := reflect.ValueOf(typedStruct.Field("Status").Field("Conditions").Value())
+	return conditionsVal.Interface().([]kmapi.Condition), nil
+}
+
+func isNotFoundError(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "[404] Not found") || strings.Contains(msg, `"code": 404`)
+}
+
 func destroyTheObject(rawStatus map[string]interface{}, res *tfschema.Resource, server *tfschema.GRPCProviderServer, tName string) error {
 	stateVal := HCL2ValueFromConfigValue(rawStatus)
 	schma := res.CoreConfigSchema()
@@ -1039,6 +1812,9 @@ func destroyTheObject(rawStatus map[string]interface{}, res *tfschema.Resource,
 		PlannedPrivate: planResp.PlannedPrivate,
 	}
 
+	if err := waitForTenant(context.Background(), server); err != nil {
+		return err
+	}
 	applyResp, err := server.ApplyResourceChange(context.Background(), applyReq)
 	if err != nil {
 		return err
@@ -1123,16 +1899,32 @@ func getStatusWithSensitiveData(gv schema.GroupVersion, rClient client.Client, c
 }
 
 func getSpecWithSensitiveData(gv schema.GroupVersion, rClient client.Client, ctx context.Context, obj *unstructured.Unstructured, jsonit jsoniter.API) (map[string]interface{}, error) {
-	data, err := meta.MarshalToJson(obj, gv)
+	rawSpec, specType, err := getSpecWithSecretData(gv, rClient, ctx, obj, jsonit)
 	if err != nil {
 		return nil, err
 	}
 
-	typedObj, err := meta.UnmarshalFromJSON(data, gv)
+	err = resolveReferences(rClient, ctx, obj, specType, rawSpec)
 	if err != nil {
 		return nil, err
 	}
 
+	return rawSpec, nil
+}
+
+// getSpecWithSecretData returns spec.resource merged with the sensitive fields of the secret of the object,
+// without resolving the references of the object
+func getSpecWithSecretData(gv schema.GroupVersion, rClient client.Client, ctx context.Context, obj *unstructured.Unstructured, jsonit jsoniter.API) (map[string]interface{}, reflect.Type, error) {
+	data, err := meta.MarshalToJson(obj, gv)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	typedObj, err := meta.UnmarshalFromJSON(data, gv)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	typedStruct := structs.New(typedObj)
 	spec := reflect.ValueOf(typedStruct.Field("Spec").Field("Resource").Value())
 	specType := reflect.TypeOf(typedStruct.Field("Spec").Field("Resource").Value())
@@ -1141,7 +1933,7 @@ func getSpecWithSensitiveData(gv schema.GroupVersion, rClient client.Client, ctx
 
 	secretRef, _, err := unstructured.NestedFieldNoCopy(obj.Object, "spec", "secretRef")
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	secretData := make(map[string]interface{})
@@ -1155,13 +1947,13 @@ func getSpecWithSensitiveData(gv schema.GroupVersion, rClient client.Client, ctx
 				Name:      secretName.(string),
 			}
 			if err := rClient.Get(ctx, req, &secret); err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 
 			if _, ok := secret.Data["resource"]; ok {
 				err = json.Unmarshal(secret.Data["resource"], &secretData)
 				if err != nil {
-					return nil, err
+					return nil, nil, err
 				}
 			}
 		}
@@ -1169,19 +1961,19 @@ func getSpecWithSensitiveData(gv schema.GroupVersion, rClient client.Client, ctx
 
 	str, err := jsonit.Marshal(specValue.Interface())
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	rawSpec := make(map[string]interface{})
 	err = json.Unmarshal(str, &rawSpec)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	if err := mergo.Merge(&rawSpec, secretData); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return rawSpec, nil
+	return rawSpec, specType, nil
 }
 
 func getProviderSecretData(rClient client.Client, ctx context.Context, obj *unstructured.Unstructured) (map[string][]byte, error) {
@@ -1366,6 +2158,17 @@ func updateStateField(rClient client.Client, ctx context.Context, intrfc map[str
 	return nil
 }
 
+// GetSensitiveData returns the sensitive fields of the given spec.resource value keyed by
+// their terraform attribute names, and whether any sensitive field is set.
+func GetSensitiveData(resource interface{}) (map[string]interface{}, bool, error) {
+	hasAnySensitiveField := false
+	secretData, _, err := processSensitiveFields(reflect.TypeOf(resource), reflect.ValueOf(resource), &hasAnySensitiveField)
+	if err != nil {
+		return nil, false, err
+	}
+	return secretData, hasAnySensitiveField, nil
+}
+
 func processSensitiveFields(r reflect.Type, v reflect.Value, hasAnySensitiveField *bool) (map[string]interface{}, bool, error) {
 	var err error
 	out := make(map[string]interface{})
@@ -1440,38 +2243,73 @@ func processSensitiveFields(r reflect.Type, v reflect.Value, hasAnySensitiveFiel
 	return out, tr, nil
 }
 
-func setProviderMeta(rClient client.Client, provider *tfschema.Provider, ctx context.Context, unstructuredObj *unstructured.Unstructured, server *tfschema.GRPCProviderServer, jsonit jsoniter.API) error {
-	jsonit = GetJSONItr(dynatrace.GetEncoder(), dynatrace.GetDecoder())
-	providerSecretData, err := getProviderSecretData(rClient, ctx, unstructuredObj)
+// getProviderServer returns the provider server configured for the object, shared by the objects using the same
+// configuration, and which API token of the configuration it uses
+func getProviderServer(rClient client.Client, provider *tfschema.Provider, ctx context.Context, unstructuredObj *unstructured.Unstructured) (*tfschema.GRPCProviderServer, string, error) {
+	mapData, secondaryToken, err := getProviderConfigMap(rClient, ctx, unstructuredObj)
 	if err != nil {
-		return err
+		return nil, "", err
 	}
-	providerSchema, err := provider.GetSchema(&terraform.ProviderSchemaRequest{})
-	if err != nil {
-		return err
+	return getPooledServer(ctx, provider, mapData, secondaryToken)
+}
+
+// getProviderConfigMap returns the configuration of the provider for the object, keyed by the terraform attribute names,
+// and the secondary API token used if the environment rejects the API token of the configuration
+func getProviderConfigMap(rClient client.Client, ctx context.Context, unstructuredObj *unstructured.Unstructured) (map[string]interface{}, string, error) {
+	if name, ok := unstructuredObj.GetAnnotations()[ProviderConfigKey]; ok {
+		pc, spec, err := GetProviderConfig(rClient, ctx, unstructuredObj.GetNamespace(), name)
+		if err != nil {
+			return nil, "", err
+		}
+		mapData, err := GetProviderConfigData(rClient, ctx, pc, spec)
+		if err != nil {
+			return nil, "", err
+		}
+		secondaryToken, err := GetSecondaryAPIToken(rClient, ctx, pc, spec)
+		if err != nil {
+			return nil, "", err
+		}
+		return mapData, secondaryToken, nil
 	}
 
-	if providerSchema.Provider == nil {
-		return fmt.Errorf("missing provider schema")
+	jsonit := GetJSONItr(dynatrace.GetEncoder(), dynatrace.GetDecoder())
+	providerSecretData, err := getProviderSecretData(rClient, ctx, unstructuredObj)
+	if err != nil {
+		return nil, "", err
 	}
 
 	providerSpec := &dynatrace.DynatraceSpec{}
 	err = jsonit.Unmarshal(providerSecretData["provider"], providerSpec)
 	if err != nil {
-		return err
+		return nil, "", err
 	}
 
 	providerDataByte, err := jsonit.Marshal(providerSpec)
 	if err != nil {
-		return err
+		return nil, "", err
 	}
 
 	mapData := make(map[string]interface{})
 	err = jsonit.Unmarshal(providerDataByte, &mapData)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return mapData, getSecondaryAPIToken(providerSecretData), nil
+}
+
+// ConfigureProvider configures the provider served by the given server using the provider
+// configuration keyed by the terraform attribute names, e.g. dt_env_url and dt_api_token.
+func ConfigureProvider(ctx context.Context, provider *tfschema.Provider, server *tfschema.GRPCProviderServer, mapData map[string]interface{}) error {
+	providerSchema, err := provider.GetSchema(&terraform.ProviderSchemaRequest{})
 	if err != nil {
 		return err
 	}
 
+	if providerSchema.Provider == nil {
+		return fmt.Errorf("missing provider schema")
+	}
+
 	configRaw := HCL2ValueFromConfigValue(mapData)
 	configPlan, err := msgpack.Marshal(configRaw, providerSchema.Provider.ImpliedType())
 	if err != nil {
@@ -1509,6 +2347,9 @@ func HCL2ValueFromConfigValue(v interface{}) cty.Value {
 	if v == nil || v == UnknownIdValue {
 		return cty.NullVal(cty.DynamicPseudoType)
 	}
+	if v == unknownVariableValue {
+		return cty.DynamicVal
+	}
 
 	switch tv := v.(type) {
 	case bool:
@@ -0,0 +1,121 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the AppsCode Community License 1.0.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://github.com/appscode/licenses/raw/1.0.0/AppsCode-Community-1.0.0.md
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	base "kubeform.dev/apimachinery/api/v1alpha1"
+)
+
+func newObject(generation, observedGeneration int64) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("alerting.dynatrace.kubeform.com/v1alpha1")
+	obj.SetKind("Profile")
+	obj.SetNamespace("default")
+	obj.SetName("profile")
+	obj.SetGeneration(generation)
+	if observedGeneration > 0 {
+		_ = unstructured.SetNestedField(obj.Object, observedGeneration, "status", "observedGeneration")
+	}
+	return obj
+}
+
+func TestReconcileOnCreate(t *testing.T) {
+	tests := []struct {
+		name         string
+		obj          *unstructured.Unstructured
+		resyncPeriod time.Duration
+		want         bool
+	}{
+		{name: "new object", obj: newObject(1, 0), resyncPeriod: 10 * time.Minute, want: true},
+		{name: "changed object", obj: newObject(2, 1), want: true},
+		// the informer lists the objects reconciled before a restart as created
+		{name: "reconciled object on startup", obj: newObject(2, 2), resyncPeriod: 10 * time.Minute, want: true},
+		{name: "reconciled object without resync", obj: newObject(2, 2), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ReconcileOnCreate(tt.obj, tt.resyncPeriod); got != tt.want {
+				t.Errorf("ReconcileOnCreate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetTerminationPolicy(t *testing.T) {
+	tests := []struct {
+		name       string
+		annotation string
+		spec       string
+		defaults   base.TerminationPolicy
+		want       base.TerminationPolicy
+		wantErr    bool
+	}{
+		{name: "nothing set", want: base.TerminationPolicyDelete},
+		{name: "spec", spec: "DoNotTerminate", want: base.TerminationPolicyDoNotTerminate},
+		{name: "annotation", annotation: "Orphan", want: TerminationPolicyOrphan},
+		{name: "annotation stricter than spec", annotation: "Orphan", spec: "Delete", want: TerminationPolicyOrphan},
+		{name: "spec stricter than annotation", annotation: "Orphan", spec: "DoNotTerminate", want: base.TerminationPolicyDoNotTerminate},
+		{name: "annotation loosening spec", annotation: "Delete", spec: "DoNotTerminate", want: base.TerminationPolicyDoNotTerminate},
+		{name: "provider default", defaults: TerminationPolicyOrphan, want: TerminationPolicyOrphan},
+		{name: "spec overriding provider default", spec: "Delete", defaults: base.TerminationPolicyDoNotTerminate, want: base.TerminationPolicyDelete},
+		{name: "unknown annotation", annotation: "Keep", spec: "Delete", wantErr: true},
+		{name: "unknown spec", spec: "delete", wantErr: true},
+		{name: "unknown provider default", defaults: "Keep", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := newObject(1, 0)
+			if tt.annotation != "" {
+				obj.SetAnnotations(map[string]string{TerminationPolicyKey: tt.annotation})
+			}
+			if tt.spec != "" {
+				_ = unstructured.SetNestedField(obj.Object, tt.spec, "spec", "terminationPolicy")
+			}
+
+			got, err := getTerminationPolicy(obj, &ProviderDefaults{TerminationPolicy: tt.defaults})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getTerminationPolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("getTerminationPolicy() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateTerminationPolicy(t *testing.T) {
+	tests := []struct {
+		policy  base.TerminationPolicy
+		wantErr bool
+	}{
+		{policy: base.TerminationPolicyDelete},
+		{policy: base.TerminationPolicyDoNotTerminate},
+		{policy: TerminationPolicyOrphan},
+		{policy: "", wantErr: true},
+		{policy: "orphan", wantErr: true},
+		{policy: "WipeOut", wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := ValidateTerminationPolicy(tt.policy); (err != nil) != tt.wantErr {
+			t.Errorf("ValidateTerminationPolicy(%q) error = %v, wantErr %v", tt.policy, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the AppsCode Community License 1.0.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://github.com/appscode/licenses/raw/1.0.0/AppsCode-Community-1.0.0.md
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	errors2 "errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/hashicorp/go-cty/cty/msgpack"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	tfschema "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	jsoniter "github.com/json-iterator/go"
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	base "kubeform.dev/apimachinery/api/v1alpha1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/runtime/inject"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// ResourceValidator validates an object with the webhook of its type, then validates spec.resource against
+// the schema of the provider, so that an invalid object is rejected when it is applied rather than when it
+// is reconciled
+type ResourceValidator struct {
+	Client    client.Client
+	Gvk       schema.GroupVersionKind
+	Provider  *tfschema.Provider
+	Resource  *tfschema.Resource
+	TypeName  string
+	JsonIt    jsoniter.API
+	Validator admission.Validator
+
+	handler admission.Handler
+	server  *tfschema.GRPCProviderServer
+}
+
+var _ admission.Handler = &ResourceValidator{}
+
+func (v *ResourceValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	resp := v.handler.Handle(ctx, req)
+	if !resp.Allowed {
+		return resp
+	}
+	if req.Operation == admissionv1.Delete {
+		oldObj := &unstructured.Unstructured{}
+		if err := oldObj.UnmarshalJSON(req.OldObject.Raw); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		// the annotation protects the object like spec.terminationPolicy does
+		if base.TerminationPolicy(oldObj.GetAnnotations()[TerminationPolicyKey]) == base.TerminationPolicyDoNotTerminate {
+			return admission.Denied(fmt.Sprintf("%s %q can't be terminated. To delete, change the %s annotation to Delete or Orphan", strings.ToLower(v.Gvk.Kind), oldObj.GetNamespace()+"/"+oldObj.GetName(), TerminationPolicyKey))
+		}
+		return resp
+	}
+	if req.Operation != admissionv1.Create && req.Operation != admissionv1.Update {
+		return resp
+	}
+
+	obj := &unstructured.Unstructured{}
+	if err := obj.UnmarshalJSON(req.Object.Raw); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if obj.GetDeletionTimestamp() != nil {
+		return resp
+	}
+	if policy, ok := obj.GetAnnotations()[TerminationPolicyKey]; ok {
+		if err := ValidateTerminationPolicy(base.TerminationPolicy(policy)); err != nil {
+			return admission.Denied(fmt.Sprintf("annotation %s: %v", TerminationPolicyKey, err))
+		}
+	}
+	if policy, _, _ := unstructured.NestedString(obj.Object, "spec", "terminationPolicy"); policy != "" {
+		if err := ValidateTerminationPolicy(base.TerminationPolicy(policy)); err != nil {
+			return admission.Denied(fmt.Sprintf("spec.terminationPolicy: %v", err))
+		}
+	}
+	if req.Operation == admissionv1.Update {
+		// the spec of an existing object is only validated when it changes, so that the controller can still
+		// update the metadata of the objects created before the validation
+		oldObj := &unstructured.Unstructured{}
+		if err := oldObj.UnmarshalJSON(req.OldObject.Raw); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		if reflect.DeepEqual(oldObj.Object["spec"], obj.Object["spec"]) && oldObj.GetAnnotations()[ReferencesKey] == obj.GetAnnotations()[ReferencesKey] {
+			return resp
+		}
+	}
+
+	warnings, err := v.validate(ctx, obj)
+	if err != nil {
+		return admission.Denied(err.Error())
+	}
+	return resp.WithWarnings(append(resp.Warnings, warnings...)...)
+}
+
+// validate validates spec.resource of the object against the schema of the provider and returns the warnings
+// of the provider. The fields set by the references are only known once the referenced objects are applied,
+// so they are not validated.
+func (v *ResourceValidator) validate(ctx context.Context, obj *unstructured.Unstructured) ([]string, error) {
+	gv := v.Gvk.GroupVersion()
+	rawSpec, resType, err := getSpecWithSecretData(gv, v.Client, ctx, obj, v.JsonIt)
+	if errors.IsNotFound(err) {
+		// the secret of the sensitive fields may be created after the object
+		return []string{fmt.Sprintf("spec.resource is not validated: %v", err)}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	refs, err := getReferences(obj)
+	if err != nil {
+		return nil, err
+	}
+	for _, ref := range refs {
+		steps, err := resourceAttributePath(resType, ref.FieldPath)
+		if err != nil {
+			return nil, fmt.Errorf("annotation %s: %v", ReferencesKey, err)
+		}
+		if _, err = setAttribute(rawSpec, steps, unknownVariableValue); err != nil {
+			return nil, fmt.Errorf("annotation %s: failed to set %s: %v", ReferencesKey, ref.FieldPath, err)
+		}
+	}
+
+	diags, err := resourceConfigDiagnostics(ctx, v.server, v.Resource, v.TypeName, rawSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	var warnings, msgs []string
+	for _, d := range diags {
+		if d.Summary == "Invalid or unknown key" || d.Summary == UpdateNotSupported {
+			continue
+		}
+		msg := d.Summary
+		if d.Detail != "" {
+			msg += ": " + d.Detail
+		}
+		if steps := attributePathSteps(d.Attribute); len(steps) > 0 {
+			msg = resourceFieldPath(resType, steps) + ": " + msg
+		}
+		if d.Severity == tfprotov5.DiagnosticSeverityWarning {
+			warnings = append(warnings, msg)
+		} else {
+			msgs = append(msgs, msg)
+		}
+	}
+	if len(msgs) > 0 {
+		return nil, errors2.New(strings.Join(msgs, "; "))
+	}
+	return warnings, nil
+}
+
+// InjectDecoder injects the decoder into the webhook of the type of the object
+func (v *ResourceValidator) InjectDecoder(d *admission.Decoder) error {
+	_, err := admission.InjectDecoderInto(d, v.handler)
+	return err
+}
+
+// InjectFunc injects the dependencies into the webhook of the type of the object
+func (v *ResourceValidator) InjectFunc(f inject.Func) error {
+	return f(v.handler)
+}
+
+func (v *ResourceValidator) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	v.handler = admission.ValidatingWebhookFor(v.Validator).Handler
+	// the schema validation doesn't need a configured provider
+	v.server = tfschema.NewGRPCProviderServer(v.Provider)
+
+	path := "/validate-" + strings.ReplaceAll(strings.ToLower(v.Gvk.Group), ".", "-") + "-" + v.Gvk.Version + "-" + strings.ToLower(v.Gvk.Kind)
+	mgr.GetWebhookServer().Register(path, &webhook.Admission{Handler: v})
+	return nil
+}
+
+// validateResourceConfig validates the configuration of the resource against the schema of the provider
+func validateResourceConfig(ctx context.Context, server *tfschema.GRPCProviderServer, res *tfschema.Resource, tName string, rawSpec map[string]interface{}) error {
+	diags, err := resourceConfigDiagnostics(ctx, server, res, tName, rawSpec)
+	if err != nil {
+		return err
+	}
+	if len(diags) > 0 {
+		return diagToError(diags)
+	}
+	return nil
+}
+
+func resourceConfigDiagnostics(ctx context.Context, server *tfschema.GRPCProviderServer, res *tfschema.Resource, tName string, rawSpec map[string]interface{}) ([]*tfprotov5.Diagnostic, error) {
+	if rawSpec["id"] == nil {
+		rawSpec["id"] = UnknownIdValue
+	}
+	rawSpecCty := HCL2ValueFromConfigValue(rawSpec)
+	initialState, err := msgpack.Marshal(rawSpecCty, res.CoreConfigSchema().ImpliedType())
+	if err != nil {
+		return nil, err
+	}
+	req := &tfprotov5.ValidateResourceTypeConfigRequest{
+		TypeName: tName,
+		Config: &tfprotov5.DynamicValue{
+			MsgPack: initialState,
+		},
+	}
+	valid, err := server.ValidateResourceTypeConfig(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return valid.Diagnostics, nil
+}
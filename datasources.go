@@ -0,0 +1,187 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the AppsCode Community License 1.0.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://github.com/appscode/licenses/raw/1.0.0/AppsCode-Community-1.0.0.md
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/gobuffalo/flect"
+	tfschema "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	crdv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"kubeform.dev/provider-dynatrace-controller/controllers"
+)
+
+const dataSourceGroup = "data.dynatrace.kubeform.com"
+
+// dataSources maps the kinds of the data.dynatrace.kubeform.com group to the data sources backing them
+var dataSources = map[string]string{
+	"AlertingProfileSet":   "dynatrace_alerting_profiles",
+	"CredentialSet":        "dynatrace_credentials",
+	"SyntheticLocation":    "dynatrace_synthetic_location",
+	"SyntheticLocationSet": "dynatrace_synthetic_locations",
+}
+
+// dataSourceCRD returns the CRD of the data source kind. The schema of spec.args and status.output follows the
+// schema of the data source.
+func dataSourceCRD(kind string) *crdv1.CustomResourceDefinition {
+	plural := strings.ToLower(flect.Pluralize(kind))
+	ds := _provider.DataSourcesMap[dataSources[kind]]
+
+	args := dataSourceSchemaProps(ds.Schema, true)
+	args.Description = "Arguments of the data source"
+	output := dataSourceSchemaProps(ds.Schema, false)
+	output.Description = "Result of the last read of the data source"
+
+	return &crdv1.CustomResourceDefinition{
+		TypeMeta: crdTypeMeta,
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   plural + "." + dataSourceGroup,
+			Labels: crdLabels,
+		},
+		Spec: crdv1.CustomResourceDefinitionSpec{
+			Group: dataSourceGroup,
+			Names: crdv1.CustomResourceDefinitionNames{
+				Plural:     plural,
+				Singular:   strings.ToLower(kind),
+				Kind:       kind,
+				ListKind:   kind + "List",
+				Categories: []string{"kubeform", "dynatrace"},
+			},
+			Scope: crdv1.NamespaceScoped,
+			Versions: []crdv1.CustomResourceDefinitionVersion{
+				{
+					Name:    "v1alpha1",
+					Served:  true,
+					Storage: true,
+					Subresources: &crdv1.CustomResourceSubresources{
+						Status: &crdv1.CustomResourceSubresourceStatus{},
+					},
+					AdditionalPrinterColumns: []crdv1.CustomResourceColumnDefinition{
+						{
+							Name:     "Phase",
+							Type:     "string",
+							JSONPath: ".status.phase",
+						},
+					},
+					Schema: &crdv1.CustomResourceValidation{
+						OpenAPIV3Schema: &crdv1.JSONSchemaProps{
+							Type: "object",
+							Properties: map[string]crdv1.JSONSchemaProps{
+								"apiVersion": {Type: "string"},
+								"kind":       {Type: "string"},
+								"metadata":   {Type: "object"},
+								"spec": {
+									Type:     "object",
+									Required: []string{"providerRef"},
+									Properties: map[string]crdv1.JSONSchemaProps{
+										"providerRef": {
+											Type: "object",
+											Properties: map[string]crdv1.JSONSchemaProps{
+												"name": {Type: "string"},
+											},
+										},
+										"args": args,
+									},
+								},
+								"status": {
+									Type: "object",
+									Properties: map[string]crdv1.JSONSchemaProps{
+										"observedGeneration": {Type: "integer", Format: "int64"},
+										"phase":              {Type: "string"},
+										"conditions":         conditionsSchemaProps,
+										"output":             output,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// dataSourceSchemaProps returns the schema of the fields of the object for the attributes of the data source,
+// only for its arguments if args is true
+func dataSourceSchemaProps(s map[string]*tfschema.Schema, args bool) crdv1.JSONSchemaProps {
+	props := crdv1.JSONSchemaProps{
+		Type:       "object",
+		Properties: make(map[string]crdv1.JSONSchemaProps),
+	}
+	for attr, sch := range s {
+		if args && !sch.Required && !sch.Optional {
+			continue
+		}
+		field := controllers.DataSourceFieldName(attr)
+		props.Properties[field] = attributeSchemaProps(sch, args)
+		if args && sch.Required {
+			props.Required = append(props.Required, field)
+		}
+	}
+	sort.Strings(props.Required)
+	return props
+}
+
+func attributeSchemaProps(sch *tfschema.Schema, args bool) crdv1.JSONSchemaProps {
+	props := crdv1.JSONSchemaProps{Description: sch.Description}
+	switch sch.Type {
+	case tfschema.TypeBool:
+		props.Type = "boolean"
+	case tfschema.TypeInt:
+		props.Type = "integer"
+		props.Format = "int64"
+	case tfschema.TypeFloat:
+		props.Type = "number"
+	case tfschema.TypeString:
+		props.Type = "string"
+	case tfschema.TypeList, tfschema.TypeSet:
+		props.Type = "array"
+		items := elemSchemaProps(sch.Elem, args)
+		props.Items = &crdv1.JSONSchemaPropsOrArray{Schema: &items}
+		if sch.MaxItems > 0 {
+			maxItems := int64(sch.MaxItems)
+			props.MaxItems = &maxItems
+		}
+	case tfschema.TypeMap:
+		props.Type = "object"
+		values := elemSchemaProps(sch.Elem, args)
+		props.AdditionalProperties = &crdv1.JSONSchemaPropsOrBool{Allows: true, Schema: &values}
+	}
+	return props
+}
+
+// elemSchemaProps returns the schema of the items of a list, set or map, which are strings if the schema
+// doesn't tell
+func elemSchemaProps(elem interface{}, args bool) crdv1.JSONSchemaProps {
+	switch e := elem.(type) {
+	case *tfschema.Resource:
+		return dataSourceSchemaProps(e.Schema, args)
+	case *tfschema.Schema:
+		return attributeSchemaProps(e, args)
+	default:
+		return crdv1.JSONSchemaProps{Type: "string"}
+	}
+}
+
+// isDataSource returns true if the kind is backed by a data source
+func isDataSource(gvk schema.GroupVersionKind) bool {
+	_, ok := dataSources[gvk.Kind]
+	return gvk.Group == dataSourceGroup && ok
+}
@@ -0,0 +1,88 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the AppsCode Community License 1.0.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://github.com/appscode/licenses/raw/1.0.0/AppsCode-Community-1.0.0.md
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/spf13/cobra"
+	"k8s.io/klog/v2"
+	dashboardv1alpha1 "kubeform.dev/provider-dynatrace-api/apis/dashboard/v1alpha1"
+	"kubeform.dev/provider-dynatrace-controller/controllers"
+	controllersdashboard "kubeform.dev/provider-dynatrace-controller/controllers/dashboard"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	exportDashboardFile   string
+	exportDashboardOutput string
+)
+
+func NewCmdExportDashboard() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "export-dashboard",
+		Short:             "Convert a Dashboard resource into the dashboard json of Dynatrace",
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := exportDashboard(); err != nil {
+				klog.Error(err, "unable to export dashboard")
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&exportDashboardFile, "filename", "f", "-", "File holding the yaml or json of the Dashboard resource, - to read it from stdin")
+	cmd.Flags().StringVarP(&exportDashboardOutput, "output", "o", "-", "File where the dashboard json is written, - to write it to stdout")
+
+	return cmd
+}
+
+func exportDashboard() error {
+	var data []byte
+	var err error
+	if exportDashboardFile == "-" {
+		data, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		data, err = ioutil.ReadFile(exportDashboardFile)
+	}
+	if err != nil {
+		return err
+	}
+
+	var obj dashboardv1alpha1.Dashboard
+	if err := yaml.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	if obj.Kind != "" && obj.Kind != "Dashboard" {
+		return fmt.Errorf("expected a Dashboard, found %s", obj.Kind)
+	}
+
+	jsonit := controllers.GetJSONItr(dashboardv1alpha1.GetEncoder(), dashboardv1alpha1.GetDecoder())
+	out, err := controllersdashboard.ResourceToDashboardJSON(&obj.Spec.Resource, _provider.ResourcesMap["dynatrace_dashboard"], jsonit)
+	if err != nil {
+		return err
+	}
+	out = append(out, '\n')
+
+	if exportDashboardOutput == "-" {
+		_, err = os.Stdout.Write(out)
+		return err
+	}
+	return ioutil.WriteFile(exportDashboardOutput, out, 0o644)
+}
@@ -0,0 +1,122 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the AppsCode Community License 1.0.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://github.com/appscode/licenses/raw/1.0.0/AppsCode-Community-1.0.0.md
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	crdv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+)
+
+var genCRDsOutputDir string
+
+var crdTypeMeta = metav1.TypeMeta{
+	APIVersion: crdv1.SchemeGroupVersion.String(),
+	Kind:       "CustomResourceDefinition",
+}
+
+// crdLabels are the labels of the CRDs of the provider, e.g. to remove them with make purge
+var crdLabels = map[string]string{
+	"app.kubernetes.io/name":    "dynatrace.kubeform.com",
+	"app.kubernetes.io/part-of": "kubeform.com",
+}
+
+// conditionsSchemaProps is the schema of status.conditions
+var conditionsSchemaProps = crdv1.JSONSchemaProps{
+	Type: "array",
+	Items: &crdv1.JSONSchemaPropsOrArray{
+		Schema: &crdv1.JSONSchemaProps{
+			Type:     "object",
+			Required: []string{"type", "status"},
+			Properties: map[string]crdv1.JSONSchemaProps{
+				"type":               {Type: "string"},
+				"status":             {Type: "string"},
+				"observedGeneration": {Type: "integer", Format: "int64"},
+				"lastTransitionTime": {Type: "string", Format: "date-time"},
+				"reason":             {Type: "string"},
+				"message":            {Type: "string"},
+			},
+		},
+	},
+}
+
+// NewCmdGenCRDs returns the command writing the CRDs of the kinds which are not part of the api module, i.e.
+// the data source kinds and the provider configurations. They are installed along with the CRDs of the api
+// module, the controller only watches them.
+func NewCmdGenCRDs() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "gen-crds",
+		Short:             "Write the CRDs of the data source kinds and of the provider configurations",
+		DisableAutoGenTag: true,
+		Hidden:            true,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := genCRDs(genCRDsOutputDir); err != nil {
+				klog.Error(err, "unable to generate crds")
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&genCRDsOutputDir, "output-dir", "crds", "Directory where the CRDs are written")
+
+	return cmd
+}
+
+func genCRDs(dir string) error {
+	var crds []*crdv1.CustomResourceDefinition
+	for kind := range dataSources {
+		crds = append(crds, dataSourceCRD(kind))
+	}
+	for kind, scope := range providerConfigScopes {
+		crds = append(crds, providerConfigCRD(kind, scope))
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for _, crd := range crds {
+		data, err := crdYAML(crd)
+		if err != nil {
+			return err
+		}
+		name := fmt.Sprintf("%s_%s.yaml", crd.Spec.Group, crd.Spec.Names.Plural)
+		if err := ioutil.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// crdYAML returns the yaml of the CRD without its status
+func crdYAML(crd *crdv1.CustomResourceDefinition) ([]byte, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(crd)
+	if err != nil {
+		return nil, err
+	}
+	unstructured.RemoveNestedField(content, "metadata", "creationTimestamp")
+	unstructured.RemoveNestedField(content, "status")
+	return yaml.Marshal(content)
+}
@@ -0,0 +1,86 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the AppsCode Community License 1.0.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://github.com/appscode/licenses/raw/1.0.0/AppsCode-Community-1.0.0.md
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// TestGenCRDs checks that the CRDs in crds/ are up to date, run make gen-crds after changing them
+func TestGenCRDs(t *testing.T) {
+	dir := t.TempDir()
+	if err := genCRDs(dir); err != nil {
+		t.Fatal(err)
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shipped, err := filepath.Glob(filepath.Join("crds", "*.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(shipped) != len(files) {
+		t.Errorf("crds/ holds %d CRDs, want %d", len(shipped), len(files))
+	}
+	for _, f := range files {
+		want, err := ioutil.ReadFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ioutil.ReadFile(filepath.Join("crds", f.Name()))
+		if err != nil {
+			t.Errorf("crds/%s is missing", f.Name())
+			continue
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("crds/%s is out of date", f.Name())
+		}
+	}
+}
+
+func TestDataSourceCRD(t *testing.T) {
+	crd := dataSourceCRD("SyntheticLocationSet")
+	if crd.Spec.Scope != "Namespaced" || len(crd.Spec.Versions) != 1 {
+		t.Fatalf("unexpected CRD %s", crd.Name)
+	}
+	schema := crd.Spec.Versions[0].Schema.OpenAPIV3Schema
+	if schema.XPreserveUnknownFields != nil {
+		t.Error("schema of a data source preserves unknown fields")
+	}
+
+	args := schema.Properties["spec"].Properties["args"]
+	locations := args.Properties["locations"]
+	if locations.Type != "array" || locations.MaxItems == nil || *locations.MaxItems != 1 {
+		t.Errorf("args.locations = %+v, want an array of at most one item", locations)
+	}
+	location := locations.Items.Schema.Properties["location"].Items.Schema
+	if ips := location.Properties["ips"]; ips.Type != "array" || ips.Items.Schema.Type != "string" {
+		t.Errorf("args.locations.location.ips = %+v, want an array of strings", ips)
+	}
+
+	output := schema.Properties["status"].Properties["output"]
+	if _, ok := output.Properties["id"]; !ok {
+		t.Error("status.output has no id")
+	}
+	if conditions := schema.Properties["status"].Properties["conditions"]; conditions.Items == nil {
+		t.Error("status.conditions has no schema of the items")
+	}
+}
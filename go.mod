@@ -3,6 +3,8 @@ module kubeform.dev/provider-dynatrace-controller
 go 1.17
 
 require (
+	github.com/dtcookie/dynatrace/api/config/dashboards v1.0.15
+	github.com/dtcookie/hcl v0.0.16
 	github.com/dynatrace-oss/terraform-provider-dynatrace v1.10.0
 	github.com/fatih/structs v1.1.0
 	github.com/go-logr/logr v0.4.0
@@ -15,10 +17,12 @@ require (
 	github.com/imdario/mergo v0.3.12
 	github.com/json-iterator/go v1.1.12
 	github.com/pkg/errors v0.9.1
+	github.com/prometheus/client_golang v1.11.0
 	github.com/spf13/cobra v1.1.3
 	go.bytebuilders.dev/audit v0.0.11
 	go.bytebuilders.dev/license-verifier v0.9.3
 	go.bytebuilders.dev/license-verifier/kubernetes v0.9.2
+	golang.org/x/time v0.0.0-20210723032227-1f47c861a9ac
 	gomodules.xyz/logs v0.0.4
 	gomodules.xyz/x v0.0.8
 	k8s.io/api v0.21.1
@@ -32,6 +36,7 @@ require (
 	kubeform.dev/terraform-backend-sdk v0.0.0-20210922115523-21574335f0db
 	sigs.k8s.io/cli-utils v0.25.0
 	sigs.k8s.io/controller-runtime v0.9.0
+	sigs.k8s.io/yaml v1.2.0
 )
 
 require (
@@ -92,7 +97,6 @@ require (
 	github.com/dtcookie/dynatrace/api/config/credentials/kubernetes v1.0.13 // indirect
 	github.com/dtcookie/dynatrace/api/config/credentials/vault v1.0.1 // indirect
 	github.com/dtcookie/dynatrace/api/config/customservices v1.0.15 // indirect
-	github.com/dtcookie/dynatrace/api/config/dashboards v1.0.15 // indirect
 	github.com/dtcookie/dynatrace/api/config/dashboards/sharing v1.0.1 // indirect
 	github.com/dtcookie/dynatrace/api/config/entityruleengine v1.0.11 // indirect
 	github.com/dtcookie/dynatrace/api/config/maintenance v1.0.9 // indirect
@@ -116,7 +120,6 @@ require (
 	github.com/dtcookie/dynatrace/rest v1.0.15 // indirect
 	github.com/dtcookie/dynatrace/terraform v1.0.5 // indirect
 	github.com/dtcookie/gojson v0.9.1 // indirect
-	github.com/dtcookie/hcl v0.0.16 // indirect
 	github.com/dtcookie/opt v1.0.0 // indirect
 	github.com/dtcookie/xjson v1.0.2 // indirect
 	github.com/emicklei/go-restful v2.9.5+incompatible // indirect
@@ -187,7 +190,6 @@ require (
 	github.com/nats-io/nkeys v0.3.0 // indirect
 	github.com/nats-io/nuid v1.0.1 // indirect
 	github.com/posener/complete v1.2.3 // indirect
-	github.com/prometheus/client_golang v1.11.0 // indirect
 	github.com/prometheus/client_model v0.2.0 // indirect
 	github.com/prometheus/common v0.26.0 // indirect
 	github.com/prometheus/procfs v0.6.0 // indirect
@@ -219,7 +221,6 @@ require (
 	golang.org/x/sys v0.0.0-20210817190340-bfb29a6856f2 // indirect
 	golang.org/x/term v0.0.0-20210220032956-6a3ed077a48d // indirect
 	golang.org/x/text v0.3.6 // indirect
-	golang.org/x/time v0.0.0-20210723032227-1f47c861a9ac // indirect
 	golang.org/x/tools v0.1.0 // indirect
 	golang.org/x/xerrors v0.0.0-20200804184101-5ec99f83aff1 // indirect
 	gomodules.xyz/clock v0.0.0-20200817085942-06523dba733f // indirect
@@ -251,7 +252,6 @@ require (
 	kmodules.xyz/resource-metadata v0.6.7 // indirect
 	kmodules.xyz/resource-metrics v0.0.5 // indirect
 	sigs.k8s.io/structured-merge-diff/v4 v4.1.2 // indirect
-	sigs.k8s.io/yaml v1.2.0 // indirect
 )
 
 replace github.com/json-iterator/go => github.com/gomodules/json-iterator v1.1.12-0.20210506053207-2a3ea71074bc
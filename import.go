@@ -0,0 +1,500 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the AppsCode Community License 1.0.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://github.com/appscode/licenses/raw/1.0.0/AppsCode-Community-1.0.0.md
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fatih/structs"
+	"github.com/gobuffalo/flect"
+	tfschema "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+	"kubeform.dev/provider-dynatrace-controller/controllers"
+	"sigs.k8s.io/yaml"
+)
+
+// listEndpoint describes the Dynatrace API calls that list the objects of a resource type
+type listEndpoint struct {
+	Paths    []string // paths relative to the environment url
+	Key      string   // key of the list in the response
+	IDKey    string   // key of the id in each entry of the list
+	IDPrefix string   // prefix of the import id of the listed objects
+}
+
+// singletonIDs are the import ids of the resource types of which every environment has exactly one object
+var singletonIDs = map[string]string{
+	"dynatrace_application_anomalies": "dynatrace_application_anomalies",
+	"dynatrace_database_anomalies":    "dynatrace_database_anomalies",
+	"dynatrace_host_anomalies":        "dynatrace_host_anomalies",
+	"dynatrace_request_namings":       "dynatrace_request_namings",
+	"dynatrace_service_anomalies":     "dynatrace_service_anomalies",
+}
+
+// listEndpoints are the list calls of the resource types of the provider. The objects of the resource types
+// which are neither listed here nor in singletonIDs can't be enumerated and are reported as unsupported.
+var listEndpoints = map[string]listEndpoint{
+	"dynatrace_alerting_profile":          {Paths: []string{"/api/config/v1/alertingProfiles"}, Key: "values", IDKey: "id"},
+	"dynatrace_application_data_privacy":  {Paths: []string{"/api/config/v1/applications/web"}, Key: "values", IDKey: "id", IDPrefix: "DATA-PRIVACY-"},
+	"dynatrace_application_error_rules":   {Paths: []string{"/api/config/v1/applications/web"}, Key: "values", IDKey: "id", IDPrefix: "ERROR-RULES-"},
+	"dynatrace_autotag":                   {Paths: []string{"/api/config/v1/autoTags"}, Key: "values", IDKey: "id"},
+	"dynatrace_aws_credentials":           {Paths: []string{"/api/config/v1/aws/credentials"}, Key: "values", IDKey: "id"},
+	"dynatrace_azure_credentials":         {Paths: []string{"/api/config/v1/azure/credentials"}, Key: "values", IDKey: "id"},
+	"dynatrace_browser_monitor":           {Paths: []string{"/api/v1/synthetic/monitors?type=BROWSER"}, Key: "monitors", IDKey: "entityId"},
+	"dynatrace_calculated_service_metric": {Paths: []string{"/api/config/v1/calculatedMetrics/service"}, Key: "values", IDKey: "id"},
+	"dynatrace_custom_anomalies":          {Paths: []string{"/api/config/v1/anomalyDetection/metricEvents"}, Key: "values", IDKey: "id"},
+	"dynatrace_custom_service": {Paths: []string{
+		"/api/config/v1/service/customServices/dotNet",
+		"/api/config/v1/service/customServices/go",
+		"/api/config/v1/service/customServices/java",
+		"/api/config/v1/service/customServices/nodeJS",
+		"/api/config/v1/service/customServices/php",
+	}, Key: "values", IDKey: "id"},
+	"dynatrace_dashboard":                {Paths: []string{"/api/config/v1/dashboards"}, Key: "dashboards", IDKey: "id"},
+	"dynatrace_disk_anomalies":           {Paths: []string{"/api/config/v1/anomalyDetection/diskEvents"}, Key: "values", IDKey: "id"},
+	"dynatrace_host_naming":              {Paths: []string{"/api/config/v1/conditionalNaming/host"}, Key: "values", IDKey: "id"},
+	"dynatrace_http_monitor":             {Paths: []string{"/api/v1/synthetic/monitors?type=HTTP"}, Key: "monitors", IDKey: "entityId"},
+	"dynatrace_k8s_credentials":          {Paths: []string{"/api/config/v1/kubernetes/credentials"}, Key: "values", IDKey: "id"},
+	"dynatrace_maintenance_window":       {Paths: []string{"/api/config/v1/maintenanceWindows"}, Key: "values", IDKey: "id"},
+	"dynatrace_management_zone":          {Paths: []string{"/api/config/v1/managementZones"}, Key: "values", IDKey: "id"},
+	"dynatrace_mobile_application":       {Paths: []string{"/api/config/v1/applications/mobile"}, Key: "values", IDKey: "id"},
+	"dynatrace_notification":             {Paths: []string{"/api/config/v1/notifications"}, Key: "values", IDKey: "id"},
+	"dynatrace_processgroup_naming":      {Paths: []string{"/api/config/v1/conditionalNaming/processGroup"}, Key: "values", IDKey: "id"},
+	"dynatrace_request_attribute":        {Paths: []string{"/api/config/v1/service/requestAttributes"}, Key: "values", IDKey: "id"},
+	"dynatrace_request_naming":           {Paths: []string{"/api/config/v1/service/requestNaming"}, Key: "values", IDKey: "id"},
+	"dynatrace_resource_attributes":      {Paths: []string{settingsObjectsPath("builtin:resource-attribute")}, Key: "items", IDKey: "objectId"},
+	"dynatrace_service_naming":           {Paths: []string{"/api/config/v1/conditionalNaming/service"}, Key: "values", IDKey: "id"},
+	"dynatrace_slo":                      {Paths: []string{"/api/v2/slo?pageSize=10000&evaluate=false"}, Key: "slo", IDKey: "id"},
+	"dynatrace_span_attribute":           {Paths: []string{settingsObjectsPath("builtin:span-attribute")}, Key: "items", IDKey: "objectId"},
+	"dynatrace_span_capture_rule":        {Paths: []string{settingsObjectsPath("builtin:span-capturing")}, Key: "items", IDKey: "objectId"},
+	"dynatrace_span_context_propagation": {Paths: []string{settingsObjectsPath("builtin:span-context-propagation")}, Key: "items", IDKey: "objectId"},
+	"dynatrace_span_entry_point":         {Paths: []string{settingsObjectsPath("builtin:span-entry-points")}, Key: "items", IDKey: "objectId"},
+	"dynatrace_web_application":          {Paths: []string{"/api/config/v1/applications/web"}, Key: "values", IDKey: "id"},
+}
+
+// settingsObjectsPath returns the path listing the settings objects of the schema, with the summary of each
+// object which is used as its name
+func settingsObjectsPath(schemaID string) string {
+	return "/api/v2/settings/objects?schemaIds=" + url.QueryEscape(schemaID) + "&scopes=environment&fields=objectId%2Csummary&pageSize=500"
+}
+
+var (
+	importEnvURL        string
+	importAPIToken      string
+	importOutputDir     string
+	importNamespace     string
+	importProviderRef   string
+	importResourceTypes []string
+	importTimeout       = 30 * time.Second
+)
+
+// importHTTPClient is the client of the list calls
+var importHTTPClient = &http.Client{}
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// listedObject is an object returned by the list call of a resource type
+type listedObject struct {
+	ID   string
+	Name string
+}
+
+func NewCmdImport() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "import",
+		Short:             "Generate Kubeform dynatrace resources from the objects of an existing environment",
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := importEnvironment(context.Background()); err != nil {
+				klog.Error(err, "unable to import environment")
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&importEnvURL, "dt-env-url", os.Getenv("DYNATRACE_ENV_URL"), "URL of the Dynatrace environment, e.g. https://abc12345.live.dynatrace.com")
+	cmd.Flags().StringVar(&importAPIToken, "dt-api-token", os.Getenv("DYNATRACE_API_TOKEN"), "API token used to read the configuration of the Dynatrace environment")
+	cmd.Flags().StringVar(&importOutputDir, "output-dir", "dynatrace", "Directory where the generated resources are written")
+	cmd.Flags().StringVar(&importNamespace, "namespace", "default", "Namespace of the generated resources")
+	cmd.Flags().StringVar(&importProviderRef, "provider-ref", "dynatrace", "Name of the provider secret referenced by the generated resources")
+	cmd.Flags().StringSliceVar(&importResourceTypes, "resource-types", importResourceTypes, "Terraform resource types to import, e.g. dynatrace_dashboard. All resource types of the provider are imported if empty.")
+	cmd.Flags().DurationVar(&importTimeout, "timeout", importTimeout, "Timeout of each request listing the objects of the environment")
+
+	return cmd
+}
+
+func importEnvironment(ctx context.Context) error {
+	if importEnvURL == "" || importAPIToken == "" {
+		return fmt.Errorf("both --dt-env-url and --dt-api-token are required")
+	}
+	importEnvURL = strings.TrimSuffix(importEnvURL, "/")
+
+	server := tfschema.NewGRPCProviderServer(_provider)
+	err := controllers.ConfigureProvider(ctx, _provider, server, map[string]interface{}{
+		"dt_env_url":           importEnvURL,
+		"dt_api_token":         importAPIToken,
+		"dt_cluster_url":       nil,
+		"dt_cluster_api_token": nil,
+	})
+	if err != nil {
+		return err
+	}
+
+	importHTTPClient.Timeout = importTimeout
+
+	kinds := getResourceKinds()
+	selected := sets.NewString(importResourceTypes...)
+	for _, tName := range selected.List() {
+		if _, ok := _provider.ResourcesMap[tName]; !ok {
+			return fmt.Errorf("%s is not a resource type of the dynatrace provider", tName)
+		}
+	}
+
+	var tNames []string
+	for tName := range _provider.ResourcesMap {
+		if selected.Len() == 0 || selected.Has(tName) {
+			tNames = append(tNames, tName)
+		}
+	}
+	sort.Strings(tNames)
+
+	unsupported := make(map[string]string)
+	// the objects of the resource types which failed to be listed, imported or written
+	failed := make(map[string][]string)
+	for _, tName := range tNames {
+		gvk, ok := kinds[tName]
+		if !ok {
+			unsupported[tName] = "no Kubeform resource is registered for it"
+			continue
+		}
+		res := _provider.ResourcesMap[tName]
+		if res.Importer == nil {
+			unsupported[tName] = "the provider doesn't support importing it"
+			continue
+		}
+
+		var objects []listedObject
+		if id, ok := singletonIDs[tName]; ok {
+			objects = []listedObject{{ID: id, Name: strings.TrimPrefix(tName, "dynatrace_")}}
+		} else if endpoint, ok := listEndpoints[tName]; ok {
+			objects, err = listObjects(endpoint)
+			if err != nil {
+				failed[tName] = append(failed[tName], fmt.Sprintf("unable to list the objects: %v", err))
+				continue
+			}
+		} else {
+			unsupported[tName] = "its objects can't be listed"
+			continue
+		}
+
+		usedNames := sets.NewString()
+		for _, listed := range objects {
+			state, exists, err := controllers.ImportObject(listed.ID, res, server, tName)
+			if err != nil {
+				failed[tName] = append(failed[tName], fmt.Sprintf("unable to import id %s: %v", listed.ID, err))
+				continue
+			}
+			if !exists {
+				continue
+			}
+
+			name := getObjectName(listed, state, usedNames)
+			data, err := generateManifest(gvk, tName, name, state)
+			if err != nil {
+				failed[tName] = append(failed[tName], fmt.Sprintf("unable to generate the manifest of id %s: %v", listed.ID, err))
+				continue
+			}
+
+			dir := filepath.Join(importOutputDir, tName)
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return err
+			}
+			if err := ioutil.WriteFile(filepath.Join(dir, name+".yaml"), data, 0o644); err != nil {
+				return err
+			}
+			klog.Infof("imported %s with id %s as %s/%s", tName, listed.ID, gvk.Kind, name)
+		}
+	}
+
+	var failedTypes []string
+	for _, tName := range tNames {
+		if reason, ok := unsupported[tName]; ok {
+			klog.Warningf("%s is not imported: %s", tName, reason)
+		}
+		if reasons, ok := failed[tName]; ok {
+			for _, reason := range reasons {
+				klog.Errorf("%s is not imported: %s", tName, reason)
+			}
+			failedTypes = append(failedTypes, tName)
+		}
+	}
+
+	if len(failedTypes) > 0 {
+		return fmt.Errorf("failed to import %s", strings.Join(failedTypes, ", "))
+	}
+	return nil
+}
+
+// getResourceKinds returns the GroupVersionKind of the Kubeform resource of every terraform resource type
+func getResourceKinds() map[string]schema.GroupVersionKind {
+	kinds := make(map[string]schema.GroupVersionKind)
+	for gvk := range scheme.AllKnownTypes() {
+		if !strings.HasSuffix(gvk.Group, "dynatrace.kubeform.com") || strings.HasSuffix(gvk.Kind, "List") {
+			continue
+		}
+		gvr := schema.GroupVersionResource{
+			Group:    gvk.Group,
+			Version:  gvk.Version,
+			Resource: strings.ToLower(flect.Pluralize(gvk.Kind)),
+		}
+		if data, ok := allJsonIt[gvr]; ok {
+			kinds[data.ResourceType] = gvk
+		}
+	}
+	return kinds
+}
+
+func listObjects(endpoint listEndpoint) ([]listedObject, error) {
+	var objects []listedObject
+	for _, path := range endpoint.Paths {
+		listed, err := listPath(endpoint, path)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, listed...)
+	}
+	return objects, nil
+}
+
+// listPath lists the objects returned by one path of the endpoint, following the pages of the APIs of version 2
+func listPath(endpoint listEndpoint, firstPath string) ([]listedObject, error) {
+	var objects []listedObject
+
+	path := firstPath
+	for {
+		req, err := http.NewRequest(http.MethodGet, importEnvURL+path, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Api-Token "+importAPIToken)
+		req.Header.Set("Accept", "application/json")
+
+		resp, err := importHTTPClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("%s (GET) %s: %s", http.StatusText(resp.StatusCode), path, string(body))
+		}
+
+		var result map[string]interface{}
+		if err := json.Unmarshal(body, &result); err != nil {
+			return nil, err
+		}
+
+		entries, _ := result[endpoint.Key].([]interface{})
+		for _, entry := range entries {
+			m, ok := entry.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			id, _ := m[endpoint.IDKey].(string)
+			if id == "" {
+				continue
+			}
+			// settings objects have a summary instead of a name
+			name, _ := m["name"].(string)
+			if name == "" {
+				name, _ = m["summary"].(string)
+			}
+			objects = append(objects, listedObject{ID: endpoint.IDPrefix + id, Name: name})
+		}
+
+		// APIs of version 2 are paginated
+		nextPageKey, _ := result["nextPageKey"].(string)
+		if nextPageKey == "" {
+			break
+		}
+		path = strings.Split(firstPath, "?")[0] + "?nextPageKey=" + url.QueryEscape(nextPageKey)
+	}
+
+	return objects, nil
+}
+
+// getObjectName returns a unique, valid resource name derived from the name of the object
+func getObjectName(listed listedObject, state map[string]interface{}, usedNames sets.String) string {
+	name := listed.Name
+	for _, key := range []string{"name", "display_name", "key"} {
+		if name != "" {
+			break
+		}
+		name, _ = state[key].(string)
+	}
+	if name == "" {
+		name = listed.ID
+	}
+
+	name = strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if len(name) > 57 {
+		name = strings.Trim(name[:57], "-")
+	}
+	if name == "" {
+		name = "object"
+	}
+
+	unique := name
+	for i := 2; usedNames.Has(unique); i++ {
+		unique = fmt.Sprintf("%s-%d", name, i)
+	}
+	usedNames.Insert(unique)
+
+	return unique
+}
+
+// generateManifest returns the yaml of the Kubeform resource for the given state. Sensitive
+// fields are written to a Secret in the same file which is referenced by spec.secretRef.
+func generateManifest(gvk schema.GroupVersionKind, tName, name string, state map[string]interface{}) ([]byte, error) {
+	typedObj, err := scheme.New(gvk)
+	if err != nil {
+		return nil, err
+	}
+
+	gvr := schema.GroupVersionResource{
+		Group:    gvk.Group,
+		Version:  gvk.Version,
+		Resource: strings.ToLower(flect.Pluralize(gvk.Kind)),
+	}
+	jsonit := getJsonItAndResType(gvr).JsonIt
+
+	stateByte, err := json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw []byte
+	raw = append(raw, []byte(`{"spec":{ "resource":`)...)
+	raw = append(raw, stateByte...)
+	raw = append(raw, []byte(`}}`)...)
+
+	err = jsonit.Unmarshal(raw, &typedObj)
+	if err != nil {
+		return nil, err
+	}
+
+	s := structs.New(typedObj)
+	secretData, hasAnySensitiveField, err := controllers.GetSensitiveData(s.Field("Spec").Field("Resource").Value())
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(typedObj)
+	if err != nil {
+		return nil, err
+	}
+	obj := &unstructured.Unstructured{Object: content}
+	obj.SetGroupVersionKind(gvk)
+	obj.SetName(name)
+	obj.SetNamespace(importNamespace)
+	unstructured.RemoveNestedField(obj.Object, "metadata", "creationTimestamp")
+	unstructured.RemoveNestedField(obj.Object, "status")
+
+	resource, _, err := unstructured.NestedMap(obj.Object, "spec", "resource")
+	if err != nil {
+		return nil, err
+	}
+	err = unstructured.SetNestedMap(obj.Object, resource, "spec", "state")
+	if err != nil {
+		return nil, err
+	}
+	err = unstructured.SetNestedField(obj.Object, importProviderRef, "spec", "providerRef", "name")
+	if err != nil {
+		return nil, err
+	}
+
+	var secret *corev1.Secret
+	if hasAnySensitiveField {
+		secret, err = newSensitiveSecret(name, secretData)
+		if err != nil {
+			return nil, err
+		}
+		err = unstructured.SetNestedField(obj.Object, secret.Name, "spec", "secretRef", "name")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := yaml.Marshal(obj.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	if secret != nil {
+		secretYAML, err := yaml.Marshal(secret)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, []byte("---\n")...)
+		data = append(data, secretYAML...)
+	}
+
+	return data, nil
+}
+
+// newSensitiveSecret returns the Secret holding the sensitive fields of the resource of the given name, in the
+// format read through spec.secretRef
+func newSensitiveSecret(name string, secretData map[string]interface{}) (*corev1.Secret, error) {
+	secretByte, err := json.Marshal(secretData)
+	if err != nil {
+		return nil, err
+	}
+	return &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name + "-" + "dynatrace" + "-" + "sensitive",
+			Namespace: importNamespace,
+		},
+		Data: map[string][]byte{
+			"resource": secretByte,
+			"state":    secretByte,
+		},
+	}, nil
+}
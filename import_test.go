@@ -0,0 +1,257 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the AppsCode Community License 1.0.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://github.com/appscode/licenses/raw/1.0.0/AppsCode-Community-1.0.0.md
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/yaml"
+)
+
+func newFakeEnvironment(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/config/v1/service/customServices/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"values":[]}`))
+	})
+	mux.HandleFunc("/api/config/v1/service/customServices/java", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"values":[{"id":"java-1","name":"Java Service"}]}`))
+	})
+	mux.HandleFunc("/api/config/v1/service/customServices/go", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"values":[{"id":"go-1","name":"Go Service"},{"name":"no id"}]}`))
+	})
+	mux.HandleFunc("/api/config/v1/applications/web", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"values":[{"id":"APPLICATION-1","name":"shop"}]}`))
+	})
+	mux.HandleFunc("/api/v2/settings/objects", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("nextPageKey") {
+		case "":
+			if r.URL.Query().Get("schemaIds") != "builtin:span-attribute" {
+				http.Error(w, "unexpected schema", http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{"items":[{"objectId":"obj-1","summary":"Span Attribute 1"}],"nextPageKey":"page 2"}`))
+		case "page 2":
+			w.Write([]byte(`{"items":[{"objectId":"obj-2","summary":"Span Attribute 2"}]}`))
+		default:
+			http.Error(w, "unexpected page", http.StatusBadRequest)
+		}
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Api-Token token" {
+			http.Error(w, "missing token", http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	importEnvURL = server.URL
+	importAPIToken = "token"
+	importHTTPClient = server.Client()
+	return server
+}
+
+func TestListObjects(t *testing.T) {
+	newFakeEnvironment(t)
+
+	tests := []struct {
+		tName string
+		want  []listedObject
+	}{
+		{
+			tName: "dynatrace_custom_service",
+			want: []listedObject{
+				{ID: "go-1", Name: "Go Service"},
+				{ID: "java-1", Name: "Java Service"},
+			},
+		},
+		{
+			tName: "dynatrace_span_attribute",
+			want: []listedObject{
+				{ID: "obj-1", Name: "Span Attribute 1"},
+				{ID: "obj-2", Name: "Span Attribute 2"},
+			},
+		},
+		{
+			tName: "dynatrace_application_error_rules",
+			want: []listedObject{
+				{ID: "ERROR-RULES-APPLICATION-1", Name: "shop"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tName, func(t *testing.T) {
+			got, err := listObjects(listEndpoints[tt.tName])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("listObjects() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListObjectsError(t *testing.T) {
+	newFakeEnvironment(t)
+	importAPIToken = "invalid"
+
+	if _, err := listObjects(listEndpoints["dynatrace_web_application"]); err == nil {
+		t.Error("listObjects() succeeded with an invalid token")
+	}
+}
+
+func TestListEndpointsMatchProvider(t *testing.T) {
+	for tName := range listEndpoints {
+		if _, ok := _provider.ResourcesMap[tName]; !ok {
+			t.Errorf("%s is not a resource type of the provider", tName)
+		}
+	}
+	for tName := range singletonIDs {
+		if _, ok := _provider.ResourcesMap[tName]; !ok {
+			t.Errorf("%s is not a resource type of the provider", tName)
+		}
+	}
+}
+
+func TestGetObjectName(t *testing.T) {
+	usedNames := sets.NewString()
+
+	tests := []struct {
+		listed listedObject
+		state  map[string]interface{}
+		want   string
+	}{
+		{listed: listedObject{ID: "1", Name: "My Dashboard"}, want: "my-dashboard"},
+		{listed: listedObject{ID: "2", Name: "My Dashboard"}, want: "my-dashboard-2"},
+		{listed: listedObject{ID: "3"}, state: map[string]interface{}{"key": "http.method"}, want: "http-method"},
+		{listed: listedObject{ID: "vu9U3hXa3q0AAAABAB"}, want: "vu9u3hxa3q0aaaabab"},
+	}
+	for _, tt := range tests {
+		if got := getObjectName(tt.listed, tt.state, usedNames); got != tt.want {
+			t.Errorf("getObjectName(%v) = %s, want %s", tt.listed, got, tt.want)
+		}
+	}
+}
+
+func TestImportEnvironmentFailure(t *testing.T) {
+	newFakeEnvironment(t)
+	importAPIToken = "invalid"
+	importOutputDir = t.TempDir()
+	importResourceTypes = []string{"dynatrace_web_application"}
+	t.Cleanup(func() { importResourceTypes = nil })
+
+	err := importEnvironment(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "dynatrace_web_application") {
+		t.Errorf("importEnvironment() error = %v, want the failed resource type", err)
+	}
+}
+
+func TestGenerateManifest(t *testing.T) {
+	importNamespace = "monitoring"
+	importProviderRef = "dynatrace"
+	gvk, ok := getResourceKinds()["dynatrace_aws_credentials"]
+	if !ok {
+		t.Fatal("no Kubeform resource is registered for dynatrace_aws_credentials")
+	}
+
+	state := map[string]interface{}{
+		"id":             "AWS-1",
+		"label":          "production",
+		"partition_type": "AWS_DEFAULT",
+		"tagged_only":    false,
+		"authentication_data": []interface{}{
+			map[string]interface{}{
+				"account_id": "123456789012",
+				"iam_role":   "dynatrace-monitoring",
+			},
+		},
+	}
+	data, err := generateManifest(gvk, "dynatrace_aws_credentials", "production", state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "---") {
+		t.Errorf("a Secret is generated for a resource without sensitive fields:\n%s", data)
+	}
+
+	var obj map[string]interface{}
+	if err := yaml.Unmarshal(data, &obj); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"apiVersion": gvk.GroupVersion().String(),
+		"kind":       gvk.Kind,
+		"metadata": map[string]interface{}{
+			"name":      "production",
+			"namespace": "monitoring",
+		},
+	}
+	for k, v := range want {
+		if !reflect.DeepEqual(obj[k], v) {
+			t.Errorf("%s = %v, want %v", k, obj[k], v)
+		}
+	}
+	if _, ok := obj["status"]; ok {
+		t.Error("the manifest has a status")
+	}
+
+	spec := obj["spec"].(map[string]interface{})
+	resource := map[string]interface{}{
+		"id":            "AWS-1",
+		"label":         "production",
+		"partitionType": "AWS_DEFAULT",
+		"taggedOnly":    false,
+		"authenticationData": map[string]interface{}{
+			"accountID": "123456789012",
+			"iamRole":   "dynatrace-monitoring",
+		},
+	}
+	if !reflect.DeepEqual(spec["resource"], resource) {
+		t.Errorf("spec.resource = %v, want %v", spec["resource"], resource)
+	}
+	if !reflect.DeepEqual(spec["state"], resource) {
+		t.Errorf("spec.state = %v, want %v", spec["state"], resource)
+	}
+	if ref := spec["providerRef"]; !reflect.DeepEqual(ref, map[string]interface{}{"name": "dynatrace"}) {
+		t.Errorf("spec.providerRef = %v, want dynatrace", ref)
+	}
+}
+
+func TestNewSensitiveSecret(t *testing.T) {
+	importNamespace = "monitoring"
+
+	secret, err := newSensitiveSecret("production", map[string]interface{}{"secret_key": "s3cr3t"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret.Name != "production-dynatrace-sensitive" || secret.Namespace != "monitoring" {
+		t.Errorf("secret is %s/%s, want monitoring/production-dynatrace-sensitive", secret.Namespace, secret.Name)
+	}
+	for _, key := range []string{"resource", "state"} {
+		if got := string(secret.Data[key]); got != `{"secret_key":"s3cr3t"}` {
+			t.Errorf("data.%s = %s, want the sensitive fields", key, got)
+		}
+	}
+}
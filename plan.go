@@ -0,0 +1,179 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the AppsCode Community License 1.0.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://github.com/appscode/licenses/raw/1.0.0/AppsCode-Community-1.0.0.md
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"kubeform.dev/provider-dynatrace-controller/controllers"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+// +kubebuilder:rbac:groups=authentication.k8s.io,resources=tokenreviews,verbs=create
+// +kubebuilder:rbac:groups=authorization.k8s.io,resources=subjectaccessreviews,verbs=create
+
+// getPlan returns the handler planning the manifest of an object posted in yaml or json, e.g. by a CI job
+// before the manifest is merged. The response is the plan of the object in json, nothing is changed in
+// Dynatrace or in the cluster. The caller authenticates with a bearer token of the cluster and must be
+// allowed to create the object in its namespace.
+func getPlan(mgr ctrl.Manager) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.Method != http.MethodPost {
+			handleErr(w, http.StatusMethodNotAllowed, fmt.Errorf("the manifest must be posted"))
+			return
+		}
+
+		reqBody, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			handleErr(w, http.StatusBadRequest, err)
+			return
+		}
+		data, err := yaml.YAMLToJSON(reqBody)
+		if err != nil {
+			handleErr(w, http.StatusBadRequest, err)
+			return
+		}
+		obj := &unstructured.Unstructured{}
+		if err := obj.UnmarshalJSON(data); err != nil {
+			handleErr(w, http.StatusBadRequest, err)
+			return
+		}
+		if obj.GetName() == "" {
+			handleErr(w, http.StatusBadRequest, fmt.Errorf("metadata.name is missing"))
+			return
+		}
+		if obj.GetNamespace() == "" {
+			obj.SetNamespace("default")
+		}
+
+		gvk := obj.GroupVersionKind()
+		mapping, err := mgr.GetRESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			handleErr(w, http.StatusBadRequest, err)
+			return
+		}
+		jsonItAndResType, ok := allJsonIt[mapping.Resource]
+		if !ok {
+			handleErr(w, http.StatusBadRequest, fmt.Errorf("%s is not a resource of the dynatrace provider", gvk))
+			return
+		}
+		res, ok := _provider.ResourcesMap[jsonItAndResType.ResourceType]
+		if !ok {
+			handleErr(w, http.StatusBadRequest, fmt.Errorf("%s is not a resource of the dynatrace provider", jsonItAndResType.ResourceType))
+			return
+		}
+
+		// planning reads the credentials in the namespace of the object with the permissions of the controller,
+		// so the caller must be allowed to create the object there
+		rClient := mgr.GetClient()
+		if code, err := authorizePlan(r, rClient, mapping.Resource, obj.GetNamespace(), obj.GetName()); err != nil {
+			handleErr(w, code, err)
+			return
+		}
+
+		// the last applied state is read from the object of the same name in the cluster, if it exists
+		current := &unstructured.Unstructured{}
+		current.SetGroupVersionKind(gvk)
+		err = rClient.Get(r.Context(), types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()}, current)
+		if errors.IsNotFound(err) {
+			current = nil
+		} else if err != nil {
+			handleErr(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		plan, err := controllers.PlanObject(rClient, _provider, r.Context(), res, gvk.GroupVersion(), obj, current, jsonItAndResType.ResourceType, jsonItAndResType.JsonIt)
+		if err != nil {
+			handleErr(w, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		out, err := json.Marshal(plan)
+		if err != nil {
+			handleErr(w, http.StatusInternalServerError, err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		//nolint:errcheck
+		w.Write(out)
+	})
+}
+
+// authorizePlan authenticates the bearer token of the request with a TokenReview and checks with a
+// SubjectAccessReview that the caller may create the object in the namespace. It returns the http status
+// code of the response if the request is denied.
+func authorizePlan(r *http.Request, rClient client.Client, gvr schema.GroupVersionResource, namespace, name string) (int, error) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" || token == r.Header.Get("Authorization") {
+		return http.StatusUnauthorized, fmt.Errorf("a bearer token is required")
+	}
+
+	review := &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{
+			Token: token,
+		},
+	}
+	if err := rClient.Create(r.Context(), review); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if !review.Status.Authenticated {
+		return http.StatusUnauthorized, fmt.Errorf("invalid bearer token: %s", review.Status.Error)
+	}
+
+	user := review.Status.User
+	extra := make(map[string]authorizationv1.ExtraValue, len(user.Extra))
+	for k, v := range user.Extra {
+		extra[k] = authorizationv1.ExtraValue(v)
+	}
+	access := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: namespace,
+				Verb:      "create",
+				Group:     gvr.Group,
+				Version:   gvr.Version,
+				Resource:  gvr.Resource,
+				Name:      name,
+			},
+			User:   user.Username,
+			Groups: user.Groups,
+			UID:    user.UID,
+			Extra:  extra,
+		},
+	}
+	if err := rClient.Create(r.Context(), access); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if !access.Status.Allowed {
+		return http.StatusForbidden, fmt.Errorf("%s is not allowed to create %s in namespace %s", user.Username, gvr.GroupResource(), namespace)
+	}
+	return http.StatusOK, nil
+}
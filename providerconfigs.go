@@ -0,0 +1,162 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the AppsCode Community License 1.0.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://github.com/appscode/licenses/raw/1.0.0/AppsCode-Community-1.0.0.md
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"strings"
+
+	"github.com/gobuffalo/flect"
+	crdv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"kubeform.dev/provider-dynatrace-controller/controllers"
+)
+
+// providerConfigScopes maps the kinds of the provider.dynatrace.kubeform.com group to their scope
+var providerConfigScopes = map[string]crdv1.ResourceScope{
+	controllers.ProviderConfigKind:        crdv1.NamespaceScoped,
+	controllers.ClusterProviderConfigKind: crdv1.ClusterScoped,
+}
+
+// providerConfigCRD returns the CRD of the ProviderConfig or ClusterProviderConfig kind
+func providerConfigCRD(kind string, scope crdv1.ResourceScope) *crdv1.CustomResourceDefinition {
+	plural := strings.ToLower(flect.Pluralize(kind))
+
+	secretKeySelector := func(description string) crdv1.JSONSchemaProps {
+		props := map[string]crdv1.JSONSchemaProps{
+			"name": {Type: "string"},
+			"key":  {Description: "Key of the token in the secret, defaults to apiToken", Type: "string"},
+		}
+		if scope == crdv1.ClusterScoped {
+			props["namespace"] = crdv1.JSONSchemaProps{Type: "string"}
+		}
+		return crdv1.JSONSchemaProps{
+			Description: description,
+			Type:        "object",
+			Required:    []string{"name"},
+			Properties:  props,
+		}
+	}
+
+	return &crdv1.CustomResourceDefinition{
+		TypeMeta: crdTypeMeta,
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   plural + "." + controllers.ProviderConfigGroup,
+			Labels: crdLabels,
+		},
+		Spec: crdv1.CustomResourceDefinitionSpec{
+			Group: controllers.ProviderConfigGroup,
+			Names: crdv1.CustomResourceDefinitionNames{
+				Plural:     plural,
+				Singular:   strings.ToLower(kind),
+				Kind:       kind,
+				ListKind:   kind + "List",
+				Categories: []string{"kubeform", "dynatrace"},
+			},
+			Scope: scope,
+			Versions: []crdv1.CustomResourceDefinitionVersion{
+				{
+					Name:    controllers.ProviderConfigVersion,
+					Served:  true,
+					Storage: true,
+					Subresources: &crdv1.CustomResourceSubresources{
+						Status: &crdv1.CustomResourceSubresourceStatus{},
+					},
+					AdditionalPrinterColumns: []crdv1.CustomResourceColumnDefinition{
+						{
+							Name:     "URL",
+							Type:     "string",
+							JSONPath: ".spec.envURL",
+						},
+						{
+							Name:     "Ready",
+							Type:     "string",
+							JSONPath: `.status.conditions[?(@.type=="Ready")].status`,
+						},
+						{
+							Name:     "Phase",
+							Type:     "string",
+							JSONPath: ".status.phase",
+						},
+					},
+					Schema: &crdv1.CustomResourceValidation{
+						OpenAPIV3Schema: &crdv1.JSONSchemaProps{
+							Type: "object",
+							Properties: map[string]crdv1.JSONSchemaProps{
+								"apiVersion": {Type: "string"},
+								"kind":       {Type: "string"},
+								"metadata":   {Type: "object"},
+								"spec": {
+									Type:     "object",
+									Required: []string{"envURL", "apiTokenSecretRef"},
+									Properties: map[string]crdv1.JSONSchemaProps{
+										"envURL": {
+											Description: "URL of the Dynatrace environment, e.g. https://abc12345.live.dynatrace.com",
+											Type:        "string",
+										},
+										"apiTokenSecretRef":          secretKeySelector("Secret holding the API token of the environment"),
+										"secondaryAPITokenSecretRef": secretKeySelector("Secret holding the API token used when the environment rejects the API token, e.g. while the tokens are rotated"),
+										"clusterURL": {
+											Description: "URL of the Dynatrace cluster, required by the cluster management resources",
+											Type:        "string",
+										},
+										"clusterAPITokenSecretRef": secretKeySelector("Secret holding the API token of the Dynatrace cluster"),
+										"defaults": {
+											Description: "Defaults of the objects using the configuration",
+											Type:        "object",
+											Properties: map[string]crdv1.JSONSchemaProps{
+												"driftPolicy": {
+													Type: "string",
+													Enum: []crdv1.JSON{
+														{Raw: []byte(`"` + controllers.DriftPolicyEnforce + `"`)},
+														{Raw: []byte(`"` + controllers.DriftPolicyReport + `"`)},
+													},
+												},
+												"terminationPolicy": {
+													Type: "string",
+													Enum: []crdv1.JSON{
+														{Raw: []byte(`"Delete"`)},
+														{Raw: []byte(`"DoNotTerminate"`)},
+														{Raw: []byte(`"` + string(controllers.TerminationPolicyOrphan) + `"`)},
+													},
+												},
+											},
+										},
+									},
+								},
+								"status": {
+									Type: "object",
+									Properties: map[string]crdv1.JSONSchemaProps{
+										"observedGeneration": {Type: "integer", Format: "int64"},
+										"phase":              {Type: "string"},
+										"conditions":         conditionsSchemaProps,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// isProviderConfig returns true if the kind is a ProviderConfig or ClusterProviderConfig
+func isProviderConfig(gvk schema.GroupVersionKind) bool {
+	_, ok := providerConfigScopes[gvk.Kind]
+	return gvk.Group == controllers.ProviderConfigGroup && ok
+}
@@ -0,0 +1,62 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the AppsCode Community License 1.0.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://github.com/appscode/licenses/raw/1.0.0/AppsCode-Community-1.0.0.md
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// isResourceEnabled returns true if the controller of the kind is enabled by --enabled-resources and
+// --disabled-resources. The provider configurations are used by every resource, so they are always enabled.
+func isResourceEnabled(gvk schema.GroupVersionKind, plural string) bool {
+	if isProviderConfig(gvk) {
+		return true
+	}
+
+	resourceType := getResourceType(gvk, plural)
+	if len(enabledResources) > 0 && !matchesResource(enabledResources, gvk, resourceType) {
+		return false
+	}
+	return !matchesResource(disabledResources, gvk, resourceType)
+}
+
+// matchesResource returns true if the kind matches one of the entries, either its API group, e.g.
+// synthetic.dynatrace.kubeform.com, its Kind, e.g. Dashboard, its Kind and API group, e.g.
+// Application.web.dynatrace.kubeform.com, or its Terraform type, e.g. dynatrace_dashboard. A Kind matches the
+// kinds of that name in every API group, e.g. Application matches both the web and the mobile applications.
+func matchesResource(entries []string, gvk schema.GroupVersionKind, resourceType string) bool {
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if strings.EqualFold(entry, gvk.Group) ||
+			strings.EqualFold(entry, gvk.Kind) ||
+			strings.EqualFold(entry, gvk.Kind+"."+gvk.Group) ||
+			(resourceType != "" && entry == resourceType) {
+			return true
+		}
+	}
+	return false
+}
+
+// getResourceType returns the Terraform type of the resource or the data source of the kind
+func getResourceType(gvk schema.GroupVersionKind, plural string) string {
+	if isDataSource(gvk) {
+		return dataSources[gvk.Kind]
+	}
+	return allJsonIt[gvk.GroupVersion().WithResource(plural)].ResourceType
+}
@@ -0,0 +1,94 @@
+/*
+Copyright AppsCode Inc. and Contributors
+
+Licensed under the AppsCode Community License 1.0.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://github.com/appscode/licenses/raw/1.0.0/AppsCode-Community-1.0.0.md
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"kubeform.dev/provider-dynatrace-controller/controllers"
+)
+
+var (
+	webApplication    = schema.GroupVersionKind{Group: "web.dynatrace.kubeform.com", Version: "v1alpha1", Kind: "Application"}
+	mobileApplication = schema.GroupVersionKind{Group: "mobile.dynatrace.kubeform.com", Version: "v1alpha1", Kind: "Application"}
+	dashboard         = schema.GroupVersionKind{Group: "dashboard.dynatrace.kubeform.com", Version: "v1alpha1", Kind: "Dashboard"}
+	providerConfig    = schema.GroupVersionKind{Group: controllers.ProviderConfigGroup, Version: "v1alpha1", Kind: controllers.ProviderConfigKind}
+)
+
+func TestMatchesResource(t *testing.T) {
+	tests := []struct {
+		name         string
+		entries      []string
+		gvk          schema.GroupVersionKind
+		resourceType string
+		want         bool
+	}{
+		{name: "group", entries: []string{"web.dynatrace.kubeform.com"}, gvk: webApplication, resourceType: "dynatrace_web_application", want: true},
+		{name: "other group", entries: []string{"web.dynatrace.kubeform.com"}, gvk: mobileApplication, resourceType: "dynatrace_mobile_application"},
+		{name: "kind", entries: []string{"Dashboard"}, gvk: dashboard, resourceType: "dynatrace_dashboard", want: true},
+		{name: "kind in any case", entries: []string{"dashboard"}, gvk: dashboard, resourceType: "dynatrace_dashboard", want: true},
+		{name: "bare kind of web applications", entries: []string{"Application"}, gvk: webApplication, resourceType: "dynatrace_web_application", want: true},
+		{name: "bare kind of mobile applications", entries: []string{"Application"}, gvk: mobileApplication, resourceType: "dynatrace_mobile_application", want: true},
+		{name: "kind and group", entries: []string{"Application.web.dynatrace.kubeform.com"}, gvk: webApplication, resourceType: "dynatrace_web_application", want: true},
+		{name: "kind and other group", entries: []string{"Application.web.dynatrace.kubeform.com"}, gvk: mobileApplication, resourceType: "dynatrace_mobile_application"},
+		{name: "terraform type", entries: []string{"dynatrace_mobile_application"}, gvk: mobileApplication, resourceType: "dynatrace_mobile_application", want: true},
+		{name: "other terraform type", entries: []string{"dynatrace_web_application"}, gvk: mobileApplication, resourceType: "dynatrace_mobile_application"},
+		{name: "spaces", entries: []string{" Dashboard "}, gvk: dashboard, resourceType: "dynatrace_dashboard", want: true},
+		{name: "one of the entries", entries: []string{"Sharing", "dynatrace_dashboard"}, gvk: dashboard, resourceType: "dynatrace_dashboard", want: true},
+		{name: "no entries", gvk: dashboard, resourceType: "dynatrace_dashboard"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchesResource(tt.entries, tt.gvk, tt.resourceType); got != tt.want {
+				t.Errorf("matchesResource(%v, %s) = %v, want %v", tt.entries, tt.gvk, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsResourceEnabled(t *testing.T) {
+	tests := []struct {
+		name     string
+		enabled  []string
+		disabled []string
+		gvk      schema.GroupVersionKind
+		plural   string
+		want     bool
+	}{
+		{name: "nothing set", gvk: dashboard, plural: "dashboards", want: true},
+		{name: "enabled", enabled: []string{"Dashboard"}, gvk: dashboard, plural: "dashboards", want: true},
+		{name: "not enabled", enabled: []string{"Dashboard"}, gvk: webApplication, plural: "applications"},
+		{name: "enabled by terraform type", enabled: []string{"dynatrace_web_application"}, gvk: webApplication, plural: "applications", want: true},
+		{name: "disabled", disabled: []string{"mobile.dynatrace.kubeform.com"}, gvk: mobileApplication, plural: "applications"},
+		{name: "disabled other group", disabled: []string{"mobile.dynatrace.kubeform.com"}, gvk: webApplication, plural: "applications", want: true},
+		{name: "disabled wins over enabled", enabled: []string{"Application"}, disabled: []string{"dynatrace_mobile_application"}, gvk: mobileApplication, plural: "applications"},
+		{name: "enabled besides disabled", enabled: []string{"Application"}, disabled: []string{"dynatrace_mobile_application"}, gvk: webApplication, plural: "applications", want: true},
+		{name: "provider config not enabled", enabled: []string{"Dashboard"}, gvk: providerConfig, plural: "providerconfigs", want: true},
+		{name: "provider config disabled", disabled: []string{controllers.ProviderConfigGroup, controllers.ProviderConfigKind}, gvk: providerConfig, plural: "providerconfigs", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enabledResources, disabledResources = tt.enabled, tt.disabled
+			t.Cleanup(func() { enabledResources, disabledResources = nil, nil })
+
+			if got := isResourceEnabled(tt.gvk, tt.plural); got != tt.want {
+				t.Errorf("isResourceEnabled(%s) = %v, want %v", tt.gvk, got, tt.want)
+			}
+		})
+	}
+}
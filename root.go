@@ -36,6 +36,9 @@ func NewRootCmd(version string) *cobra.Command {
 
 	rootCmd.AddCommand(v.NewCmdVersion())
 	rootCmd.AddCommand(NewCmdRun(version))
+	rootCmd.AddCommand(NewCmdImport())
+	rootCmd.AddCommand(NewCmdExportDashboard())
+	rootCmd.AddCommand(NewCmdGenCRDs())
 
 	return rootCmd
 }
@@ -21,14 +21,18 @@ package main
 import (
 	"fmt"
 	"os"
+	"time"
 
 	// +kubebuilder:scaffold:imports
 
+	dynatrace "github.com/dynatrace-oss/terraform-provider-dynatrace/provider"
 	"github.com/spf13/cobra"
 	auditlib "go.bytebuilders.dev/audit/lib"
 	licenseapi "go.bytebuilders.dev/license-verifier/apis/licenses/v1alpha1"
 	license "go.bytebuilders.dev/license-verifier/kubernetes"
+	arv1 "k8s.io/api/admissionregistration/v1"
 	"k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
@@ -42,6 +46,7 @@ import (
 	"kmodules.xyz/client-go/tools/cli"
 	"kmodules.xyz/client-go/tools/queue"
 	dynatracescheme "kubeform.dev/provider-dynatrace-api/client/clientset/versioned/scheme"
+	"kubeform.dev/provider-dynatrace-controller/controllers"
 	ctrl "sigs.k8s.io/controller-runtime"
 )
 
@@ -51,13 +56,27 @@ var (
 )
 
 var (
-	licenseFile             string
-	enableValidatingWebhook bool
-	webhookName             string
-	webhookNamespace        string
-	metricsAddr             string
-	enableLeaderElection    bool
-	probeAddr               string
+	licenseFile              string
+	enableValidatingWebhook  bool
+	webhookName              string
+	webhookNamespace         string
+	webhookFailurePolicy     string
+	webhookTimeoutSeconds    int32
+	webhookNamespaceSelector string
+	webhookSelfSignedCerts   bool
+	webhookCertSecret        string
+	webhookCertValidity      time.Duration
+	metricsAddr              string
+	enableLeaderElection     bool
+	probeAddr                string
+	resyncPeriod             time.Duration
+	dynatraceQPS             float64
+	dynatraceBurst           int
+	enabledResources         []string
+	disabledResources        []string
+	stateLocking             bool
+	stateLockTimeout         time.Duration
+	stateLockLeaseDuration   time.Duration
 )
 
 func init() {
@@ -75,6 +94,18 @@ func NewCmdRun(version string) *cobra.Command {
 
 			ctrl.SetLogger(klogr.New())
 
+			if enableValidatingWebhook || webhookSelfSignedCerts {
+				if err := validateWebhookFlags(); err != nil {
+					setupLog.Error(err, "invalid webhook flags")
+					os.Exit(1)
+				}
+			}
+
+			// requests of the provider to Dynatrace share a token bucket per tenant
+			controllers.SetupRateLimiting(dynatraceQPS, dynatraceBurst)
+			// every provider configuration is served by its own provider instance
+			controllers.SetupProviderPool(dynatrace.Provider)
+
 			ctx := ctrl.SetupSignalHandler()
 
 			mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
@@ -84,12 +115,17 @@ func NewCmdRun(version string) *cobra.Command {
 				HealthProbeBindAddress: probeAddr,
 				LeaderElection:         enableLeaderElection,
 				LeaderElectionID:       "dynatrace.kubeform.com",
+				CertDir:                webhookCertDir,
 			})
 			if err != nil {
 				setupLog.Error(err, "unable to start manager")
 				os.Exit(1)
 			}
 			cfg := mgr.GetConfig()
+			// the kubernetes state backend reads the state from the api server
+			controllers.SetupKubernetesBackend(mgr.GetAPIReader())
+			// the remote state is locked while it is changed
+			controllers.SetupStateLocking(stateLocking, stateLockTimeout, stateLockLeaseDuration)
 
 			restrictToNamespace := queue.NamespaceDemo
 			if licenseFile != "" {
@@ -127,6 +163,16 @@ func NewCmdRun(version string) *cobra.Command {
 			crdClient := clientset.NewForConfigOrDie(cfg)
 			vwcClient := admissionregistrationv1.NewForConfigOrDie(cfg)
 
+			if webhookSelfSignedCerts {
+				// the serving certificate is needed before the webhook server starts, it is rotated afterwards
+				kc := kubernetes.NewForConfigOrDie(cfg)
+				if _, err := ensureWebhookCerts(ctx, kc); err != nil {
+					setupLog.Error(err, "unable to set up the webhook certificate")
+					os.Exit(1)
+				}
+				go rotateWebhookCerts(ctx, kc, vwcClient)
+			}
+
 			err = watchCRD(ctx, crdClient, vwcClient, ctx.Done(), mgr, auditor, restrictToNamespace)
 			if err != nil {
 				setupLog.Error(err, "unable to watch crds")
@@ -139,6 +185,7 @@ func NewCmdRun(version string) *cobra.Command {
 			go license.VerifyLicensePeriodically(mgr.GetConfig(), licenseFile, ctx.Done())
 
 			mgr.GetWebhookServer().Register("/tf", getTF(dClient))
+			mgr.GetWebhookServer().Register("/plan", getPlan(mgr))
 
 			if auditor != nil {
 				if err := auditor.SetupSiteInfoPublisherWithManager(mgr); err != nil {
@@ -165,6 +212,37 @@ func NewCmdRun(version string) *cobra.Command {
 	cmd.Flags().BoolVar(&enableValidatingWebhook, "enable-validating-webhook", false, "Enable validating webhook")
 	cmd.Flags().StringVar(&webhookName, "webhook-name", "webhook-service", "Webhook name")
 	cmd.Flags().StringVar(&webhookNamespace, "webhook-namespace", "kube-system", "Webhook namespace")
+	cmd.Flags().StringVar(&webhookFailurePolicy, "webhook-failure-policy", string(arv1.Fail), "The failure policy of the validating webhooks, one of Fail, Ignore")
+	cmd.Flags().Int32Var(&webhookTimeoutSeconds, "webhook-timeout", 10, "The number of seconds the api server waits for the validating webhooks, between 1 and 30")
+	cmd.Flags().StringVar(&webhookNamespaceSelector, "webhook-namespace-selector", "", "The label selector of the namespaces whose objects are validated by the validating webhooks, e.g. kubeform.com/validate!=false. All namespaces are selected by default.")
+	cmd.Flags().BoolVar(&webhookSelfSignedCerts, "webhook-self-signed-certs", false, "Generate and rotate a self-signed serving certificate of the webhook server, stored in the secret --webhook-cert-secret in --webhook-namespace, and inject its CA bundle into the validating webhooks")
+	cmd.Flags().StringVar(&webhookCertSecret, "webhook-cert-secret", "provider-dynatrace-controller-webhook-cert", "The secret holding the self-signed serving certificate of the webhook server")
+	cmd.Flags().DurationVar(&webhookCertValidity, "webhook-cert-validity", 365*24*time.Hour, "The validity of the self-signed serving certificate of the webhook server, it is rotated when a third of its validity is left")
+	cmd.Flags().DurationVar(&resyncPeriod, "resync-period", 10*time.Minute, "The interval at which every object is refreshed from Dynatrace to detect drift. Set to 0 to disable periodic refresh.")
+	cmd.Flags().Float64Var(&dynatraceQPS, "dynatrace-qps", 10, "The maximum number of calls of the provider per second sent to a Dynatrace tenant, e.g. to read or apply an object. A call may send several requests to the tenant. Set to 0 to disable rate limiting.")
+	cmd.Flags().IntVar(&dynatraceBurst, "dynatrace-burst", 20, "The maximum burst of calls of the provider sent to a Dynatrace tenant.")
+	cmd.Flags().StringSliceVar(&enabledResources, "enabled-resources", enabledResources, "API groups, kinds or Terraform types of the resources to reconcile, e.g. synthetic.dynatrace.kubeform.com, Dashboard, Application.web.dynatrace.kubeform.com or dynatrace_dashboard. A kind without API group matches the kind in every group, e.g. Application matches both the web and the mobile applications. All resources are reconciled if empty.")
+	cmd.Flags().StringSliceVar(&disabledResources, "disabled-resources", disabledResources, "API groups, kinds or Terraform types of the resources not to reconcile, in the same form as --enabled-resources. It takes precedence over --enabled-resources.")
+	cmd.Flags().BoolVar(&stateLocking, "state-lock", true, "Lock the remote state of the objects while it is changed, if the backend supports locking")
+	cmd.Flags().DurationVar(&stateLockTimeout, "state-lock-timeout", 30*time.Second, "How long a reconcile waits for the lock of a remote state held by someone else, before it is retried later")
+	cmd.Flags().DurationVar(&stateLockLeaseDuration, "state-lock-lease-duration", 10*time.Minute, "How long a lock of a remote state taken by the controller is held at most, before it is considered expired and taken over")
 
 	return cmd
 }
+
+// validateWebhookFlags checks the flags of the validating webhooks before they are registered
+func validateWebhookFlags() error {
+	if p := arv1.FailurePolicyType(webhookFailurePolicy); p != arv1.Fail && p != arv1.Ignore {
+		return fmt.Errorf("--webhook-failure-policy must be one of %s, %s", arv1.Fail, arv1.Ignore)
+	}
+	if webhookTimeoutSeconds < 1 || webhookTimeoutSeconds > 30 {
+		return fmt.Errorf("--webhook-timeout must be between 1 and 30 seconds")
+	}
+	if _, err := metav1.ParseToLabelSelector(webhookNamespaceSelector); err != nil {
+		return fmt.Errorf("--webhook-namespace-selector: %v", err)
+	}
+	if webhookSelfSignedCerts && webhookCertValidity < 24*time.Hour {
+		return fmt.Errorf("--webhook-cert-validity must be at least 24h")
+	}
+	return nil
+}
@@ -21,8 +21,6 @@ package main
 import (
 	"context"
 	"fmt"
-	"io/ioutil"
-	"os"
 	"strings"
 	"sync"
 	"time"
@@ -31,14 +29,17 @@ import (
 	"github.com/gobuffalo/flect"
 	auditlib "go.bytebuilders.dev/audit/lib"
 	arv1 "k8s.io/api/admissionregistration/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
 	informers "k8s.io/apiextensions-apiserver/pkg/client/informers/externalversions"
+	listers "k8s.io/apiextensions-apiserver/pkg/client/listers/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	admissionregistrationv1 "k8s.io/client-go/kubernetes/typed/admissionregistration/v1"
-	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
+	"kmodules.xyz/client-go/tools/queue"
 	alertingv1alpha1 "kubeform.dev/provider-dynatrace-api/apis/alerting/v1alpha1"
 	applicationv1alpha1 "kubeform.dev/provider-dynatrace-api/apis/application/v1alpha1"
 	autotagv1alpha1 "kubeform.dev/provider-dynatrace-api/apis/autotag/v1alpha1"
@@ -67,6 +68,7 @@ import (
 	spanv1alpha1 "kubeform.dev/provider-dynatrace-api/apis/span/v1alpha1"
 	userv1alpha1 "kubeform.dev/provider-dynatrace-api/apis/user/v1alpha1"
 	webv1alpha1 "kubeform.dev/provider-dynatrace-api/apis/web/v1alpha1"
+	"kubeform.dev/provider-dynatrace-controller/controllers"
 	controllersalerting "kubeform.dev/provider-dynatrace-controller/controllers/alerting"
 	controllersapplication "kubeform.dev/provider-dynatrace-controller/controllers/application"
 	controllersautotag "kubeform.dev/provider-dynatrace-controller/controllers/autotag"
@@ -76,6 +78,7 @@ import (
 	controllerscalculated "kubeform.dev/provider-dynatrace-controller/controllers/calculated"
 	controllerscustom "kubeform.dev/provider-dynatrace-controller/controllers/custom"
 	controllersdashboard "kubeform.dev/provider-dynatrace-controller/controllers/dashboard"
+	controllersdata "kubeform.dev/provider-dynatrace-controller/controllers/data"
 	controllersdatabase "kubeform.dev/provider-dynatrace-controller/controllers/database"
 	controllersdisk "kubeform.dev/provider-dynatrace-controller/controllers/disk"
 	controllersenvironment "kubeform.dev/provider-dynatrace-controller/controllers/environment"
@@ -88,6 +91,7 @@ import (
 	controllersmobile "kubeform.dev/provider-dynatrace-controller/controllers/mobile"
 	controllersnotification "kubeform.dev/provider-dynatrace-controller/controllers/notification"
 	controllersprocessgroup "kubeform.dev/provider-dynatrace-controller/controllers/processgroup"
+	controllersproviderconfig "kubeform.dev/provider-dynatrace-controller/controllers/providerconfig"
 	controllersrequest "kubeform.dev/provider-dynatrace-controller/controllers/request"
 	controllersresource "kubeform.dev/provider-dynatrace-controller/controllers/resource"
 	controllersservice "kubeform.dev/provider-dynatrace-controller/controllers/service"
@@ -101,88 +105,164 @@ import (
 
 var _provider = dynatrace.Provider()
 
-var runningControllers = struct {
-	sync.RWMutex
-	mp map[schema.GroupVersionKind]bool
-}{mp: make(map[schema.GroupVersionKind]bool)}
+// crdControllers holds the controllers of the dynatrace CRDs
+var crdControllers = struct {
+	sync.Mutex
+	// the version of the kind of a CRD which is reconciled, keyed by the name of the CRD
+	running map[string]schema.GroupVersionKind
+	// the controllers and the webhooks set up with the manager, they can't be removed from a running manager
+	controllers map[schema.GroupVersionKind]bool
+	webhooks    map[schema.GroupVersionKind]bool
+}{
+	running:     make(map[string]schema.GroupVersionKind),
+	controllers: make(map[schema.GroupVersionKind]bool),
+	webhooks:    make(map[schema.GroupVersionKind]bool),
+}
+
+// crdMaxRetries is the number of times a CRD is synced again after a failure before it is dropped,
+// the CRD is synced again on the next resync of the informer
+const crdMaxRetries = 10
 
 func watchCRD(ctx context.Context, crdClient *clientset.Clientset, vwcClient *admissionregistrationv1.AdmissionregistrationV1Client, stopCh <-chan struct{}, mgr manager.Manager, auditor *auditlib.EventPublisher, restrictToNamespace string) error {
 	informerFactory := informers.NewSharedInformerFactory(crdClient, time.Second*30)
 	i := informerFactory.Apiextensions().V1().CustomResourceDefinitions().Informer()
 	l := informerFactory.Apiextensions().V1().CustomResourceDefinitions().Lister()
 
-	i.AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc: func(obj interface{}) {
-			var key string
-			key, err := cache.MetaNamespaceKeyFunc(obj)
-			if err != nil {
-				klog.Error(err)
-				return
-			}
+	// every CRD is synced on its own and retried when it fails, so that a broken CRD doesn't stop the others
+	w := queue.New("CRD", crdMaxRetries, 1, func(name string) error {
+		return syncCRD(ctx, l, vwcClient, mgr, auditor, restrictToNamespace, name)
+	})
+	i.AddEventHandler(queue.DefaultEventHandler(w.GetQueue(), metav1.NamespaceAll))
 
-			_, name, err := cache.SplitMetaNamespaceKey(key)
-			if err != nil {
-				klog.Error(err)
-				return
-			}
+	informerFactory.Start(stopCh)
+	w.Run(stopCh)
 
-			crd, err := l.Get(name)
-			if err != nil {
-				klog.Error(err)
-				return
-			}
-			if strings.Contains(crd.Spec.Group, "dynatrace.kubeform.com") {
-				gvk := schema.GroupVersionKind{
-					Group:   crd.Spec.Group,
-					Version: crd.Spec.Versions[0].Name,
-					Kind:    crd.Spec.Names.Kind,
-				}
+	return nil
+}
+
+// syncCRD starts the controller of the served version of a dynatrace CRD, and stops it when the CRD is removed
+// or another version is served
+func syncCRD(ctx context.Context, l listers.CustomResourceDefinitionLister, vwcClient *admissionregistrationv1.AdmissionregistrationV1Client, mgr manager.Manager, auditor *auditlib.EventPublisher, restrictToNamespace string, name string) error {
+	crdControllers.Lock()
+	defer crdControllers.Unlock()
+
+	crd, err := l.Get(name)
+	if errors.IsNotFound(err) {
+		return stopCRDController(vwcClient, name)
+	}
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(crd.Spec.Group, "dynatrace.kubeform.com") {
+		return nil
+	}
 
-				// check whether this gvk came before, if no then start the controller
-				runningControllers.RLock()
-				_, ok := runningControllers.mp[gvk]
-				runningControllers.RUnlock()
+	version, ok := crdVersion(crd)
+	if !ok {
+		klog.Infof("none of the versions of %s is served", name)
+		return stopCRDController(vwcClient, name)
+	}
+	gvk := schema.GroupVersionKind{
+		Group:   crd.Spec.Group,
+		Version: version,
+		Kind:    crd.Spec.Names.Kind,
+	}
 
-				if !ok {
-					runningControllers.Lock()
-					runningControllers.mp[gvk] = true
-					runningControllers.Unlock()
+	if !isResourceEnabled(gvk, crd.Spec.Names.Plural) {
+		klog.V(4).Infof("the controller of %s is disabled", gvk)
+		return stopCRDController(vwcClient, name)
+	}
 
-					if enableValidatingWebhook {
-						// add dynamic ValidatingWebhookConfiguration
+	old, running := crdControllers.running[name]
+	if running && old == gvk {
+		return nil
+	}
 
-						// create empty VWC if the group has come for the first time
-						err := createEmptyVWC(vwcClient, gvk)
-						if err != nil {
-							klog.Error(err)
-							return
-						}
+	// the controller of the previous version keeps running until the new one is started
+	err = startCRDController(ctx, vwcClient, mgr, gvk, auditor, restrictToNamespace)
+	if err != nil {
+		return fmt.Errorf("unable to start the controller of %s: %v", gvk, err)
+	}
+	if running {
+		klog.Infof("stopped the controller of %s, %s is served instead", old, gvk.Version)
+		controllers.StopKind(old)
+	}
+	crdControllers.running[name] = gvk
+	klog.Infof("started the controller of %s", gvk)
 
-						// update
-						err = updateVWC(vwcClient, gvk)
-						if err != nil {
-							klog.Error(err)
-							return
-						}
+	return nil
+}
 
-						err = SetupWebhook(mgr, gvk)
-						if err != nil {
-							setupLog.Error(err, "unable to enable webhook")
-							os.Exit(1)
-						}
-					}
+// crdVersion returns the version of the CRD to reconcile, the storage version unless it isn't served
+func crdVersion(crd *apiextensionsv1.CustomResourceDefinition) (string, bool) {
+	for _, v := range crd.Spec.Versions {
+		if v.Storage && v.Served {
+			return v.Name, true
+		}
+	}
+	for _, v := range crd.Spec.Versions {
+		if v.Served {
+			return v.Name, true
+		}
+	}
+	return "", false
+}
 
-					err = SetupManager(ctx, mgr, gvk, auditor, restrictToNamespace)
-					if err != nil {
-						setupLog.Error(err, "unable to start manager")
-						os.Exit(1)
-					}
-				}
+func startCRDController(ctx context.Context, vwcClient *admissionregistrationv1.AdmissionregistrationV1Client, mgr manager.Manager, gvk schema.GroupVersionKind, auditor *auditlib.EventPublisher, restrictToNamespace string) error {
+	// data sources are read-only, so their objects don't need to be validated
+	if enableValidatingWebhook && !isDataSource(gvk) {
+		if !crdControllers.webhooks[gvk] {
+			err := SetupWebhook(mgr, gvk)
+			if err != nil {
+				return err
 			}
-		},
-	})
+			crdControllers.webhooks[gvk] = true
+		}
 
-	informerFactory.Start(stopCh)
+		// add dynamic ValidatingWebhookConfiguration
+
+		// create empty VWC if the group has come for the first time
+		err := createEmptyVWC(vwcClient, gvk)
+		if err != nil {
+			return err
+		}
+
+		// update
+		err = updateVWC(vwcClient, gvk)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !crdControllers.controllers[gvk] {
+		err := SetupManager(ctx, mgr, gvk, auditor, restrictToNamespace)
+		if err != nil {
+			return err
+		}
+		crdControllers.controllers[gvk] = true
+	}
+	controllers.StartKind(gvk)
+
+	return nil
+}
+
+// stopCRDController stops the controller of a removed CRD. The objects of the CRD are removed along with it,
+// so the controller only needs to ignore the events still queued.
+func stopCRDController(vwcClient *admissionregistrationv1.AdmissionregistrationV1Client, name string) error {
+	gvk, ok := crdControllers.running[name]
+	if !ok {
+		return nil
+	}
+
+	if enableValidatingWebhook && !isDataSource(gvk) {
+		err := removeVWC(vwcClient, gvk)
+		if err != nil {
+			return err
+		}
+	}
+	controllers.StopKind(gvk)
+	delete(crdControllers.running, name)
+	klog.Infof("stopped the controller of %s", gvk)
 
 	return nil
 }
@@ -222,37 +302,52 @@ func updateVWC(vwcClient *admissionregistrationv1.AdmissionregistrationV1Client,
 		return err
 	}
 
-	path := "/validate-" + strings.ReplaceAll(strings.ToLower(gvk.Group), ".", "-") + "-v1alpha1-" + strings.ToLower(gvk.Kind)
-	fail := arv1.Fail
+	path := "/validate-" + strings.ReplaceAll(strings.ToLower(gvk.Group), ".", "-") + "-" + gvk.Version + "-" + strings.ToLower(gvk.Kind)
+	port := int32(443)
+	failurePolicy := arv1.FailurePolicyType(webhookFailurePolicy)
+	matchPolicy := arv1.Equivalent
 	sideEffects := arv1.SideEffectClassNone
-	admissionReviewVersions := []string{"v1beta1"}
+	scope := arv1.AllScopes
+	timeoutSeconds := webhookTimeoutSeconds
+	// the first version supported by the api server is used
+	admissionReviewVersions := []string{"v1", "v1beta1"}
+
+	namespaceSelector, err := metav1.ParseToLabelSelector(webhookNamespaceSelector)
+	if err != nil {
+		return err
+	}
+
+	operations := []arv1.OperationType{
+		arv1.Create,
+		arv1.Update,
+		arv1.Delete,
+	}
+	if isProviderConfig(gvk) {
+		// the API token is checked when the configuration is created or changed
+		operations = []arv1.OperationType{
+			arv1.Create,
+			arv1.Update,
+		}
+	}
 
 	rules := []arv1.RuleWithOperations{
 		{
-			Operations: []arv1.OperationType{
-				arv1.Delete,
-				arv1.Update,
-			},
+			Operations: operations,
 			Rule: arv1.Rule{
 				APIGroups:   []string{strings.ToLower(gvk.Group)},
 				APIVersions: []string{gvk.Version},
 				Resources:   []string{strings.ToLower(flect.Pluralize(gvk.Kind))},
+				Scope:       &scope,
 			},
 		},
 	}
 
-	data, err := ioutil.ReadFile("/tmp/k8s-webhook-server/serving-certs/ca.crt")
+	data, err := getCABundle()
 	if err != nil {
 		return err
 	}
 
 	name := strings.ToLower(gvk.Kind) + "." + gvk.Group
-	for _, webhook := range vwc.Webhooks {
-		if webhook.Name == name {
-			return nil
-		}
-	}
-
 	newWebhook := arv1.ValidatingWebhook{
 		Name: name,
 		ClientConfig: arv1.WebhookClientConfig{
@@ -260,16 +355,36 @@ func updateVWC(vwcClient *admissionregistrationv1.AdmissionregistrationV1Client,
 				Namespace: webhookNamespace,
 				Name:      webhookName,
 				Path:      &path,
+				Port:      &port,
 			},
 			CABundle: data,
 		},
 		Rules:                   rules,
-		FailurePolicy:           &fail,
+		FailurePolicy:           &failurePolicy,
+		MatchPolicy:             &matchPolicy,
+		NamespaceSelector:       namespaceSelector,
+		ObjectSelector:          &metav1.LabelSelector{},
 		SideEffects:             &sideEffects,
+		TimeoutSeconds:          &timeoutSeconds,
 		AdmissionReviewVersions: admissionReviewVersions,
 	}
 
-	vwc.Webhooks = append(vwc.Webhooks, newWebhook)
+	// the webhook registered by a previous run is updated, e.g. when the CA bundle or the flags changed
+	found := false
+	for idx := range vwc.Webhooks {
+		if vwc.Webhooks[idx].Name != name {
+			continue
+		}
+		if equality.Semantic.DeepEqual(vwc.Webhooks[idx], newWebhook) {
+			return nil
+		}
+		vwc.Webhooks[idx] = newWebhook
+		found = true
+		break
+	}
+	if !found {
+		vwc.Webhooks = append(vwc.Webhooks, newWebhook)
+	}
 
 	_, err = vwcClient.ValidatingWebhookConfigurations().Update(context.TODO(), vwc, metav1.UpdateOptions{})
 	if err != nil {
@@ -279,6 +394,49 @@ func updateVWC(vwcClient *admissionregistrationv1.AdmissionregistrationV1Client,
 	return nil
 }
 
+// refreshVWCs updates the webhooks of the running controllers, e.g. to inject a new CA bundle
+func refreshVWCs(vwcClient *admissionregistrationv1.AdmissionregistrationV1Client) error {
+	crdControllers.Lock()
+	defer crdControllers.Unlock()
+
+	for _, gvk := range crdControllers.running {
+		if isDataSource(gvk) {
+			continue
+		}
+		if err := updateVWC(vwcClient, gvk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeVWC removes the webhook of the kind from the ValidatingWebhookConfiguration of its group
+func removeVWC(vwcClient *admissionregistrationv1.AdmissionregistrationV1Client, gvk schema.GroupVersionKind) error {
+	vwcName := strings.ReplaceAll(strings.ToLower(gvk.Group), ".", "-")
+	vwc, err := vwcClient.ValidatingWebhookConfigurations().Get(context.TODO(), vwcName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	name := strings.ToLower(gvk.Kind) + "." + gvk.Group
+	var webhooks []arv1.ValidatingWebhook
+	for _, webhook := range vwc.Webhooks {
+		if webhook.Name != name {
+			webhooks = append(webhooks, webhook)
+		}
+	}
+	if len(webhooks) == len(vwc.Webhooks) {
+		return nil
+	}
+	vwc.Webhooks = webhooks
+
+	_, err = vwcClient.ValidatingWebhookConfigurations().Update(context.TODO(), vwc, metav1.UpdateOptions{})
+	return err
+}
+
 func SetupManager(ctx context.Context, mgr manager.Manager, gvk schema.GroupVersionKind, auditor *auditlib.EventPublisher, restrictToNamespace string) error {
 	switch gvk {
 	case schema.GroupVersionKind{
@@ -287,13 +445,15 @@ func SetupManager(ctx context.Context, mgr manager.Manager, gvk schema.GroupVers
 		Kind:    "Profile",
 	}:
 		if err := (&controllersalerting.ProfileReconciler{
-			Client:   mgr.GetClient(),
-			Log:      ctrl.Log.WithName("controllers").WithName("Profile"),
-			Scheme:   mgr.GetScheme(),
-			Gvk:      gvk,
-			Provider: _provider,
-			Resource: _provider.ResourcesMap["dynatrace_alerting_profile"],
-			TypeName: "dynatrace_alerting_profile",
+			Client:       mgr.GetClient(),
+			Log:          ctrl.Log.WithName("controllers").WithName("Profile"),
+			Scheme:       mgr.GetScheme(),
+			Recorder:     mgr.GetEventRecorderFor("provider-dynatrace-controller"),
+			Gvk:          gvk,
+			Provider:     _provider,
+			Resource:     _provider.ResourcesMap["dynatrace_alerting_profile"],
+			TypeName:     "dynatrace_alerting_profile",
+			ResyncPeriod: resyncPeriod,
 		}).SetupWithManager(ctx, mgr, auditor, restrictToNamespace); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "Profile")
 			return err
@@ -304,13 +464,15 @@ func SetupManager(ctx context.Context, mgr manager.Manager, gvk schema.GroupVers
 		Kind:    "Anomalies",
 	}:
 		if err := (&controllersapplication.AnomaliesReconciler{
-			Client:   mgr.GetClient(),
-			Log:      ctrl.Log.WithName("controllers").WithName("Anomalies"),
-			Scheme:   mgr.GetScheme(),
-			Gvk:      gvk,
-			Provider: _provider,
-			Resource: _provider.ResourcesMap["dynatrace_application_anomalies"],
-			TypeName: "dynatrace_application_anomalies",
+			Client:       mgr.GetClient(),
+			Log:          ctrl.Log.WithName("controllers").WithName("Anomalies"),
+			Scheme:       mgr.GetScheme(),
+			Recorder:     mgr.GetEventRecorderFor("provider-dynatrace-controller"),
+			Gvk:          gvk,
+			Provider:     _provider,
+			Resource:     _provider.ResourcesMap["dynatrace_application_anomalies"],
+			TypeName:     "dynatrace_application_anomalies",
+			ResyncPeriod: resyncPeriod,
 		}).SetupWithManager(ctx, mgr, auditor, restrictToNamespace); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "Anomalies")
 			return err
@@ -321,13 +483,15 @@ func SetupManager(ctx context.Context, mgr manager.Manager, gvk schema.GroupVers
 		Kind:    "DataPrivacy",
 	}:
 		if err := (&controllersapplication.DataPrivacyReconciler{
-			Client:   mgr.GetClient(),
-			Log:      ctrl.Log.WithName("controllers").WithName("DataPrivacy"),
-			Scheme:   mgr.GetScheme(),
-			Gvk:      gvk,
-			Provider: _provider,
-			Resource: _provider.ResourcesMap["dynatrace_application_data_privacy"],
-			TypeName: "dynatrace_application_data_privacy",
+			Client:       mgr.GetClient(),
+			Log:          ctrl.Log.WithName("controllers").WithName("DataPrivacy"),
+			Scheme:       mgr.GetScheme(),
+			Recorder:     mgr.GetEventRecorderFor("provider-dynatrace-controller"),
+			Gvk:          gvk,
+			Provider:     _provider,
+			Resource:     _provider.ResourcesMap["dynatrace_application_data_privacy"],
+			TypeName:     "dynatrace_application_data_privacy",
+			ResyncPeriod: resyncPeriod,
 		}).SetupWithManager(ctx, mgr, auditor, restrictToNamespace); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "DataPrivacy")
 			return err
@@ -338,13 +502,15 @@ func SetupManager(ctx context.Context, mgr manager.Manager, gvk schema.GroupVers
 		Kind:    "ErrorRules",
 	}:
 		if err := (&controllersapplication.ErrorRulesReconciler{
-			Client:   mgr.GetClient(),
-			Log:      ctrl.Log.WithName("controllers").WithName("ErrorRules"),
-			Scheme:   mgr.GetScheme(),
-			Gvk:      gvk,
-			Provider: _provider,
-			Resource: _provider.ResourcesMap["dynatrace_application_error_rules"],
-			TypeName: "dynatrace_application_error_rules",
+			Client:       mgr.GetClient(),
+			Log:          ctrl.Log.WithName("controllers").WithName("ErrorRules"),
+			Scheme:       mgr.GetScheme(),
+			Recorder:     mgr.GetEventRecorderFor("provider-dynatrace-controller"),
+			Gvk:          gvk,
+			Provider:     _provider,
+			Resource:     _provider.ResourcesMap["dynatrace_application_error_rules"],
+			TypeName:     "dynatrace_application_error_rules",
+			ResyncPeriod: resyncPeriod,
 		}).SetupWithManager(ctx, mgr, auditor, restrictToNamespace); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "ErrorRules")
 			return err
@@ -355,13 +521,15 @@ func SetupManager(ctx context.Context, mgr manager.Manager, gvk schema.GroupVers
 		Kind:    "Autotag",
 	}:
 		if err := (&controllersautotag.AutotagReconciler{
-			Client:   mgr.GetClient(),
-			Log:      ctrl.Log.WithName("controllers").WithName("Autotag"),
-			Scheme:   mgr.GetScheme(),
-			Gvk:      gvk,
-			Provider: _provider,
-			Resource: _provider.ResourcesMap["dynatrace_autotag"],
-			TypeName: "dynatrace_autotag",
+			Client:       mgr.GetClient(),
+			Log:          ctrl.Log.WithName("controllers").WithName("Autotag"),
+			Scheme:       mgr.GetScheme(),
+			Recorder:     mgr.GetEventRecorderFor("provider-dynatrace-controller"),
+			Gvk:          gvk,
+			Provider:     _provider,
+			Resource:     _provider.ResourcesMap["dynatrace_autotag"],
+			TypeName:     "dynatrace_autotag",
+			ResyncPeriod: resyncPeriod,
 		}).SetupWithManager(ctx, mgr, auditor, restrictToNamespace); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "Autotag")
 			return err
@@ -372,13 +540,15 @@ func SetupManager(ctx context.Context, mgr manager.Manager, gvk schema.GroupVers
 		Kind:    "Credentials",
 	}:
 		if err := (&controllersaws.CredentialsReconciler{
-			Client:   mgr.GetClient(),
-			Log:      ctrl.Log.WithName("controllers").WithName("Credentials"),
-			Scheme:   mgr.GetScheme(),
-			Gvk:      gvk,
-			Provider: _provider,
-			Resource: _provider.ResourcesMap["dynatrace_aws_credentials"],
-			TypeName: "dynatrace_aws_credentials",
+			Client:       mgr.GetClient(),
+			Log:          ctrl.Log.WithName("controllers").WithName("Credentials"),
+			Scheme:       mgr.GetScheme(),
+			Recorder:     mgr.GetEventRecorderFor("provider-dynatrace-controller"),
+			Gvk:          gvk,
+			Provider:     _provider,
+			Resource:     _provider.ResourcesMap["dynatrace_aws_credentials"],
+			TypeName:     "dynatrace_aws_credentials",
+			ResyncPeriod: resyncPeriod,
 		}).SetupWithManager(ctx, mgr, auditor, restrictToNamespace); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "Credentials")
 			return err
@@ -389,13 +559,15 @@ func SetupManager(ctx context.Context, mgr manager.Manager, gvk schema.GroupVers
 		Kind:    "Credentials",
 	}:
 		if err := (&controllersazure.CredentialsReconciler{
-			Client:   mgr.GetClient(),
-			Log:      ctrl.Log.WithName("controllers").WithName("Credentials"),
-			Scheme:   mgr.GetScheme(),
-			Gvk:      gvk,
-			Provider: _provider,
-			Resource: _provider.ResourcesMap["dynatrace_azure_credentials"],
-			TypeName: "dynatrace_azure_credentials",
+			Client:       mgr.GetClient(),
+			Log:          ctrl.Log.WithName("controllers").WithName("Credentials"),
+			Scheme:       mgr.GetScheme(),
+			Recorder:     mgr.GetEventRecorderFor("provider-dynatrace-controller"),
+			Gvk:          gvk,
+			Provider:     _provider,
+			Resource:     _provider.ResourcesMap["dynatrace_azure_credentials"],
+			TypeName:     "dynatrace_azure_credentials",
+			ResyncPeriod: resyncPeriod,
 		}).SetupWithManager(ctx, mgr, auditor, restrictToNamespace); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "Credentials")
 			return err
@@ -406,13 +578,15 @@ func SetupManager(ctx context.Context, mgr manager.Manager, gvk schema.GroupVers
 		Kind:    "Monitor",
 	}:
 		if err := (&controllersbrowser.MonitorReconciler{
-			Client:   mgr.GetClient(),
-			Log:      ctrl.Log.WithName("controllers").WithName("Monitor"),
-			Scheme:   mgr.GetScheme(),
-			Gvk:      gvk,
-			Provider: _provider,
-			Resource: _provider.ResourcesMap["dynatrace_browser_monitor"],
-			TypeName: "dynatrace_browser_monitor",
+			Client:       mgr.GetClient(),
+			Log:          ctrl.Log.WithName("controllers").WithName("Monitor"),
+			Scheme:       mgr.GetScheme(),
+			Recorder:     mgr.GetEventRecorderFor("provider-dynatrace-controller"),
+			Gvk:          gvk,
+			Provider:     _provider,
+			Resource:     _provider.ResourcesMap["dynatrace_browser_monitor"],
+			TypeName:     "dynatrace_browser_monitor",
+			ResyncPeriod: resyncPeriod,
 		}).SetupWithManager(ctx, mgr, auditor, restrictToNamespace); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "Monitor")
 			return err
@@ -423,13 +597,15 @@ func SetupManager(ctx context.Context, mgr manager.Manager, gvk schema.GroupVers
 		Kind:    "ServiceMetric",
 	}:
 		if err := (&controllerscalculated.ServiceMetricReconciler{
-			Client:   mgr.GetClient(),
-			Log:      ctrl.Log.WithName("controllers").WithName("ServiceMetric"),
-			Scheme:   mgr.GetScheme(),
-			Gvk:      gvk,
-			Provider: _provider,
-			Resource: _provider.ResourcesMap["dynatrace_calculated_service_metric"],
-			TypeName: "dynatrace_calculated_service_metric",
+			Client:       mgr.GetClient(),
+			Log:          ctrl.Log.WithName("controllers").WithName("ServiceMetric"),
+			Scheme:       mgr.GetScheme(),
+			Recorder:     mgr.GetEventRecorderFor("provider-dynatrace-controller"),
+			Gvk:          gvk,
+			Provider:     _provider,
+			Resource:     _provider.ResourcesMap["dynatrace_calculated_service_metric"],
+			TypeName:     "dynatrace_calculated_service_metric",
+			ResyncPeriod: resyncPeriod,
 		}).SetupWithManager(ctx, mgr, auditor, restrictToNamespace); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "ServiceMetric")
 			return err
@@ -440,13 +616,15 @@ func SetupManager(ctx context.Context, mgr manager.Manager, gvk schema.GroupVers
 		Kind:    "Anomalies",
 	}:
 		if err := (&controllerscustom.AnomaliesReconciler{
-			Client:   mgr.GetClient(),
-			Log:      ctrl.Log.WithName("controllers").WithName("Anomalies"),
-			Scheme:   mgr.GetScheme(),
-			Gvk:      gvk,
-			Provider: _provider,
-			Resource: _provider.ResourcesMap["dynatrace_custom_anomalies"],
-			TypeName: "dynatrace_custom_anomalies",
+			Client:       mgr.GetClient(),
+			Log:          ctrl.Log.WithName("controllers").WithName("Anomalies"),
+			Scheme:       mgr.GetScheme(),
+			Recorder:     mgr.GetEventRecorderFor("provider-dynatrace-controller"),
+			Gvk:          gvk,
+			Provider:     _provider,
+			Resource:     _provider.ResourcesMap["dynatrace_custom_anomalies"],
+			TypeName:     "dynatrace_custom_anomalies",
+			ResyncPeriod: resyncPeriod,
 		}).SetupWithManager(ctx, mgr, auditor, restrictToNamespace); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "Anomalies")
 			return err
@@ -457,13 +635,15 @@ func SetupManager(ctx context.Context, mgr manager.Manager, gvk schema.GroupVers
 		Kind:    "Service",
 	}:
 		if err := (&controllerscustom.ServiceReconciler{
-			Client:   mgr.GetClient(),
-			Log:      ctrl.Log.WithName("controllers").WithName("Service"),
-			Scheme:   mgr.GetScheme(),
-			Gvk:      gvk,
-			Provider: _provider,
-			Resource: _provider.ResourcesMap["dynatrace_custom_service"],
-			TypeName: "dynatrace_custom_service",
+			Client:       mgr.GetClient(),
+			Log:          ctrl.Log.WithName("controllers").WithName("Service"),
+			Scheme:       mgr.GetScheme(),
+			Recorder:     mgr.GetEventRecorderFor("provider-dynatrace-controller"),
+			Gvk:          gvk,
+			Provider:     _provider,
+			Resource:     _provider.ResourcesMap["dynatrace_custom_service"],
+			TypeName:     "dynatrace_custom_service",
+			ResyncPeriod: resyncPeriod,
 		}).SetupWithManager(ctx, mgr, auditor, restrictToNamespace); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "Service")
 			return err
@@ -474,13 +654,15 @@ func SetupManager(ctx context.Context, mgr manager.Manager, gvk schema.GroupVers
 		Kind:    "Dashboard",
 	}:
 		if err := (&controllersdashboard.DashboardReconciler{
-			Client:   mgr.GetClient(),
-			Log:      ctrl.Log.WithName("controllers").WithName("Dashboard"),
-			Scheme:   mgr.GetScheme(),
-			Gvk:      gvk,
-			Provider: _provider,
-			Resource: _provider.ResourcesMap["dynatrace_dashboard"],
-			TypeName: "dynatrace_dashboard",
+			Client:       mgr.GetClient(),
+			Log:          ctrl.Log.WithName("controllers").WithName("Dashboard"),
+			Scheme:       mgr.GetScheme(),
+			Recorder:     mgr.GetEventRecorderFor("provider-dynatrace-controller"),
+			Gvk:          gvk,
+			Provider:     _provider,
+			Resource:     _provider.ResourcesMap["dynatrace_dashboard"],
+			TypeName:     "dynatrace_dashboard",
+			ResyncPeriod: resyncPeriod,
 		}).SetupWithManager(ctx, mgr, auditor, restrictToNamespace); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "Dashboard")
 			return err
@@ -491,30 +673,110 @@ func SetupManager(ctx context.Context, mgr manager.Manager, gvk schema.GroupVers
 		Kind:    "Sharing",
 	}:
 		if err := (&controllersdashboard.SharingReconciler{
-			Client:   mgr.GetClient(),
-			Log:      ctrl.Log.WithName("controllers").WithName("Sharing"),
-			Scheme:   mgr.GetScheme(),
-			Gvk:      gvk,
-			Provider: _provider,
-			Resource: _provider.ResourcesMap["dynatrace_dashboard_sharing"],
-			TypeName: "dynatrace_dashboard_sharing",
+			Client:       mgr.GetClient(),
+			Log:          ctrl.Log.WithName("controllers").WithName("Sharing"),
+			Scheme:       mgr.GetScheme(),
+			Recorder:     mgr.GetEventRecorderFor("provider-dynatrace-controller"),
+			Gvk:          gvk,
+			Provider:     _provider,
+			Resource:     _provider.ResourcesMap["dynatrace_dashboard_sharing"],
+			TypeName:     "dynatrace_dashboard_sharing",
+			ResyncPeriod: resyncPeriod,
 		}).SetupWithManager(ctx, mgr, auditor, restrictToNamespace); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "Sharing")
 			return err
 		}
+	case schema.GroupVersionKind{
+		Group:   "data.dynatrace.kubeform.com",
+		Version: "v1alpha1",
+		Kind:    "AlertingProfileSet",
+	}:
+		if err := (&controllersdata.DataSourceReconciler{
+			Client:       mgr.GetClient(),
+			Log:          ctrl.Log.WithName("controllers").WithName("AlertingProfileSet"),
+			Scheme:       mgr.GetScheme(),
+			Recorder:     mgr.GetEventRecorderFor("provider-dynatrace-controller"),
+			Gvk:          gvk,
+			Provider:     _provider,
+			DataSource:   _provider.DataSourcesMap["dynatrace_alerting_profiles"],
+			TypeName:     "dynatrace_alerting_profiles",
+			ResyncPeriod: resyncPeriod,
+		}).SetupWithManager(ctx, mgr, auditor, restrictToNamespace); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "AlertingProfileSet")
+			return err
+		}
+	case schema.GroupVersionKind{
+		Group:   "data.dynatrace.kubeform.com",
+		Version: "v1alpha1",
+		Kind:    "CredentialSet",
+	}:
+		if err := (&controllersdata.DataSourceReconciler{
+			Client:       mgr.GetClient(),
+			Log:          ctrl.Log.WithName("controllers").WithName("CredentialSet"),
+			Scheme:       mgr.GetScheme(),
+			Recorder:     mgr.GetEventRecorderFor("provider-dynatrace-controller"),
+			Gvk:          gvk,
+			Provider:     _provider,
+			DataSource:   _provider.DataSourcesMap["dynatrace_credentials"],
+			TypeName:     "dynatrace_credentials",
+			ResyncPeriod: resyncPeriod,
+		}).SetupWithManager(ctx, mgr, auditor, restrictToNamespace); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "CredentialSet")
+			return err
+		}
+	case schema.GroupVersionKind{
+		Group:   "data.dynatrace.kubeform.com",
+		Version: "v1alpha1",
+		Kind:    "SyntheticLocation",
+	}:
+		if err := (&controllersdata.DataSourceReconciler{
+			Client:       mgr.GetClient(),
+			Log:          ctrl.Log.WithName("controllers").WithName("SyntheticLocation"),
+			Scheme:       mgr.GetScheme(),
+			Recorder:     mgr.GetEventRecorderFor("provider-dynatrace-controller"),
+			Gvk:          gvk,
+			Provider:     _provider,
+			DataSource:   _provider.DataSourcesMap["dynatrace_synthetic_location"],
+			TypeName:     "dynatrace_synthetic_location",
+			ResyncPeriod: resyncPeriod,
+		}).SetupWithManager(ctx, mgr, auditor, restrictToNamespace); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "SyntheticLocation")
+			return err
+		}
+	case schema.GroupVersionKind{
+		Group:   "data.dynatrace.kubeform.com",
+		Version: "v1alpha1",
+		Kind:    "SyntheticLocationSet",
+	}:
+		if err := (&controllersdata.DataSourceReconciler{
+			Client:       mgr.GetClient(),
+			Log:          ctrl.Log.WithName("controllers").WithName("SyntheticLocationSet"),
+			Scheme:       mgr.GetScheme(),
+			Recorder:     mgr.GetEventRecorderFor("provider-dynatrace-controller"),
+			Gvk:          gvk,
+			Provider:     _provider,
+			DataSource:   _provider.DataSourcesMap["dynatrace_synthetic_locations"],
+			TypeName:     "dynatrace_synthetic_locations",
+			ResyncPeriod: resyncPeriod,
+		}).SetupWithManager(ctx, mgr, auditor, restrictToNamespace); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "SyntheticLocationSet")
+			return err
+		}
 	case schema.GroupVersionKind{
 		Group:   "database.dynatrace.kubeform.com",
 		Version: "v1alpha1",
 		Kind:    "Anomalies",
 	}:
 		if err := (&controllersdatabase.AnomaliesReconciler{
-			Client:   mgr.GetClient(),
-			Log:      ctrl.Log.WithName("controllers").WithName("Anomalies"),
-			Scheme:   mgr.GetScheme(),
-			Gvk:      gvk,
-			Provider: _provider,
-			Resource: _provider.ResourcesMap["dynatrace_database_anomalies"],
-			TypeName: "dynatrace_database_anomalies",
+			Client:       mgr.GetClient(),
+			Log:          ctrl.Log.WithName("controllers").WithName("Anomalies"),
+			Scheme:       mgr.GetScheme(),
+			Recorder:     mgr.GetEventRecorderFor("provider-dynatrace-controller"),
+			Gvk:          gvk,
+			Provider:     _provider,
+			Resource:     _provider.ResourcesMap["dynatrace_database_anomalies"],
+			TypeName:     "dynatrace_database_anomalies",
+			ResyncPeriod: resyncPeriod,
 		}).SetupWithManager(ctx, mgr, auditor, restrictToNamespace); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "Anomalies")
 			return err
@@ -525,13 +787,15 @@ func SetupManager(ctx context.Context, mgr manager.Manager, gvk schema.GroupVers
 		Kind:    "Anomalies",
 	}:
 		if err := (&controllersdisk.AnomaliesReconciler{
-			Client:   mgr.GetClient(),
-			Log:      ctrl.Log.WithName("controllers").WithName("Anomalies"),
-			Scheme:   mgr.GetScheme(),
-			Gvk:      gvk,
-			Provider: _provider,
-			Resource: _provider.ResourcesMap["dynatrace_disk_anomalies"],
-			TypeName: "dynatrace_disk_anomalies",
+			Client:       mgr.GetClient(),
+			Log:          ctrl.Log.WithName("controllers").WithName("Anomalies"),
+			Scheme:       mgr.GetScheme(),
+			Recorder:     mgr.GetEventRecorderFor("provider-dynatrace-controller"),
+			Gvk:          gvk,
+			Provider:     _provider,
+			Resource:     _provider.ResourcesMap["dynatrace_disk_anomalies"],
+			TypeName:     "dynatrace_disk_anomalies",
+			ResyncPeriod: resyncPeriod,
 		}).SetupWithManager(ctx, mgr, auditor, restrictToNamespace); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "Anomalies")
 			return err
@@ -542,13 +806,15 @@ func SetupManager(ctx context.Context, mgr manager.Manager, gvk schema.GroupVers
 		Kind:    "Environment",
 	}:
 		if err := (&controllersenvironment.EnvironmentReconciler{
-			Client:   mgr.GetClient(),
-			Log:      ctrl.Log.WithName("controllers").WithName("Environment"),
-			Scheme:   mgr.GetScheme(),
-			Gvk:      gvk,
-			Provider: _provider,
-			Resource: _provider.ResourcesMap["dynatrace_environment"],
-			TypeName: "dynatrace_environment",
+			Client:       mgr.GetClient(),
+			Log:          ctrl.Log.WithName("controllers").WithName("Environment"),
+			Scheme:       mgr.GetScheme(),
+			Recorder:     mgr.GetEventRecorderFor("provider-dynatrace-controller"),
+			Gvk:          gvk,
+			Provider:     _provider,
+			Resource:     _provider.ResourcesMap["dynatrace_environment"],
+			TypeName:     "dynatrace_environment",
+			ResyncPeriod: resyncPeriod,
 		}).SetupWithManager(ctx, mgr, auditor, restrictToNamespace); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "Environment")
 			return err
@@ -559,13 +825,15 @@ func SetupManager(ctx context.Context, mgr manager.Manager, gvk schema.GroupVers
 		Kind:    "Anomalies",
 	}:
 		if err := (&controllershost.AnomaliesReconciler{
-			Client:   mgr.GetClient(),
-			Log:      ctrl.Log.WithName("controllers").WithName("Anomalies"),
-			Scheme:   mgr.GetScheme(),
-			Gvk:      gvk,
-			Provider: _provider,
-			Resource: _provider.ResourcesMap["dynatrace_host_anomalies"],
-			TypeName: "dynatrace_host_anomalies",
+			Client:       mgr.GetClient(),
+			Log:          ctrl.Log.WithName("controllers").WithName("Anomalies"),
+			Scheme:       mgr.GetScheme(),
+			Recorder:     mgr.GetEventRecorderFor("provider-dynatrace-controller"),
+			Gvk:          gvk,
+			Provider:     _provider,
+			Resource:     _provider.ResourcesMap["dynatrace_host_anomalies"],
+			TypeName:     "dynatrace_host_anomalies",
+			ResyncPeriod: resyncPeriod,
 		}).SetupWithManager(ctx, mgr, auditor, restrictToNamespace); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "Anomalies")
 			return err
@@ -576,13 +844,15 @@ func SetupManager(ctx context.Context, mgr manager.Manager, gvk schema.GroupVers
 		Kind:    "Naming",
 	}:
 		if err := (&controllershost.NamingReconciler{
-			Client:   mgr.GetClient(),
-			Log:      ctrl.Log.WithName("controllers").WithName("Naming"),
-			Scheme:   mgr.GetScheme(),
-			Gvk:      gvk,
-			Provider: _provider,
-			Resource: _provider.ResourcesMap["dynatrace_host_naming"],
-			TypeName: "dynatrace_host_naming",
+			Client:       mgr.GetClient(),
+			Log:          ctrl.Log.WithName("controllers").WithName("Naming"),
+			Scheme:       mgr.GetScheme(),
+			Recorder:     mgr.GetEventRecorderFor("provider-dynatrace-controller"),
+			Gvk:          gvk,
+			Provider:     _provider,
+			Resource:     _provider.ResourcesMap["dynatrace_host_naming"],
+			TypeName:     "dynatrace_host_naming",
+			ResyncPeriod: resyncPeriod,
 		}).SetupWithManager(ctx, mgr, auditor, restrictToNamespace); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "Naming")
 			return err
@@ -593,13 +863,15 @@ func SetupManager(ctx context.Context, mgr manager.Manager, gvk schema.GroupVers
 		Kind:    "Monitor",
 	}:
 		if err := (&controllershttp.MonitorReconciler{
-			Client:   mgr.GetClient(),
-			Log:      ctrl.Log.WithName("controllers").WithName("Monitor"),
-			Scheme:   mgr.GetScheme(),
-			Gvk:      gvk,
-			Provider: _provider,
-			Resource: _provider.ResourcesMap["dynatrace_http_monitor"],
-			TypeName: "dynatrace_http_monitor",
+			Client:       mgr.GetClient(),
+			Log:          ctrl.Log.WithName("controllers").WithName("Monitor"),
+			Scheme:       mgr.GetScheme(),
+			Recorder:     mgr.GetEventRecorderFor("provider-dynatrace-controller"),
+			Gvk:          gvk,
+			Provider:     _provider,
+			Resource:     _provider.ResourcesMap["dynatrace_http_monitor"],
+			TypeName:     "dynatrace_http_monitor",
+			ResyncPeriod: resyncPeriod,
 		}).SetupWithManager(ctx, mgr, auditor, restrictToNamespace); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "Monitor")
 			return err
@@ -613,6 +885,7 @@ func SetupManager(ctx context.Context, mgr manager.Manager, gvk schema.GroupVers
 			Client:   mgr.GetClient(),
 			Log:      ctrl.Log.WithName("controllers").WithName("Credentials"),
 			Scheme:   mgr.GetScheme(),
+			Recorder: mgr.GetEventRecorderFor("provider-dynatrace-controller"),
 			Gvk:      gvk,
 			Provider: _provider,
 			Resource: _provider.ResourcesMap["dynatrace_k8s_credentials"],
@@ -627,13 +900,15 @@ func SetupManager(ctx context.Context, mgr manager.Manager, gvk schema.GroupVers
 		Kind:    "Requests",
 	}:
 		if err := (&controllerskey.RequestsReconciler{
-			Client:   mgr.GetClient(),
-			Log:      ctrl.Log.WithName("controllers").WithName("Requests"),
-			Scheme:   mgr.GetScheme(),
-			Gvk:      gvk,
-			Provider: _provider,
-			Resource: _provider.ResourcesMap["dynatrace_key_requests"],
-			TypeName: "dynatrace_key_requests",
+			Client:       mgr.GetClient(),
+			Log:          ctrl.Log.WithName("controllers").WithName("Requests"),
+			Scheme:       mgr.GetScheme(),
+			Recorder:     mgr.GetEventRecorderFor("provider-dynatrace-controller"),
+			Gvk:          gvk,
+			Provider:     _provider,
+			Resource:     _provider.ResourcesMap["dynatrace_key_requests"],
+			TypeName:     "dynatrace_key_requests",
+			ResyncPeriod: resyncPeriod,
 		}).SetupWithManager(ctx, mgr, auditor, restrictToNamespace); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "Requests")
 			return err
@@ -644,13 +919,15 @@ func SetupManager(ctx context.Context, mgr manager.Manager, gvk schema.GroupVers
 		Kind:    "Window",
 	}:
 		if err := (&controllersmaintenance.WindowReconciler{
-			Client:   mgr.GetClient(),
-			Log:      ctrl.Log.WithName("controllers").WithName("Window"),
-			Scheme:   mgr.GetScheme(),
-			Gvk:      gvk,
-			Provider: _provider,
-			Resource: _provider.ResourcesMap["dynatrace_maintenance_window"],
-			TypeName: "dynatrace_maintenance_window",
+			Client:       mgr.GetClient(),
+			Log:          ctrl.Log.WithName("controllers").WithName("Window"),
+			Scheme:       mgr.GetScheme(),
+			Recorder:     mgr.GetEventRecorderFor("provider-dynatrace-controller"),
+			Gvk:          gvk,
+			Provider:     _provider,
+			Resource:     _provider.ResourcesMap["dynatrace_maintenance_window"],
+			TypeName:     "dynatrace_maintenance_window",
+			ResyncPeriod: resyncPeriod,
 		}).SetupWithManager(ctx, mgr, auditor, restrictToNamespace); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "Window")
 			return err
@@ -661,13 +938,15 @@ func SetupManager(ctx context.Context, mgr manager.Manager, gvk schema.GroupVers
 		Kind:    "Zone",
 	}:
 		if err := (&controllersmanagement.ZoneReconciler{
-			Client:   mgr.GetClient(),
-			Log:      ctrl.Log.WithName("controllers").WithName("Zone"),
-			Scheme:   mgr.GetScheme(),
-			Gvk:      gvk,
-			Provider: _provider,
-			Resource: _provider.ResourcesMap["dynatrace_management_zone"],
-			TypeName: "dynatrace_management_zone",
+			Client:       mgr.GetClient(),
+			Log:          ctrl.Log.WithName("controllers").WithName("Zone"),
+			Scheme:       mgr.GetScheme(),
+			Recorder:     mgr.GetEventRecorderFor("provider-dynatrace-controller"),
+			Gvk:          gvk,
+			Provider:     _provider,
+			Resource:     _provider.ResourcesMap["dynatrace_management_zone"],
+			TypeName:     "dynatrace_management_zone",
+			ResyncPeriod: resyncPeriod,
 		}).SetupWithManager(ctx, mgr, auditor, restrictToNamespace); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "Zone")
 			return err
@@ -678,13 +957,15 @@ func SetupManager(ctx context.Context, mgr manager.Manager, gvk schema.GroupVers
 		Kind:    "Application",
 	}:
 		if err := (&controllersmobile.ApplicationReconciler{
-			Client:   mgr.GetClient(),
-			Log:      ctrl.Log.WithName("controllers").WithName("Application"),
-			Scheme:   mgr.GetScheme(),
-			Gvk:      gvk,
-			Provider: _provider,
-			Resource: _provider.ResourcesMap["dynatrace_mobile_application"],
-			TypeName: "dynatrace_mobile_application",
+			Client:       mgr.GetClient(),
+			Log:          ctrl.Log.WithName("controllers").WithName("Application"),
+			Scheme:       mgr.GetScheme(),
+			Recorder:     mgr.GetEventRecorderFor("provider-dynatrace-controller"),
+			Gvk:          gvk,
+			Provider:     _provider,
+			Resource:     _provider.ResourcesMap["dynatrace_mobile_application"],
+			TypeName:     "dynatrace_mobile_application",
+			ResyncPeriod: resyncPeriod,
 		}).SetupWithManager(ctx, mgr, auditor, restrictToNamespace); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "Application")
 			return err
@@ -695,13 +976,15 @@ func SetupManager(ctx context.Context, mgr manager.Manager, gvk schema.GroupVers
 		Kind:    "Notification",
 	}:
 		if err := (&controllersnotification.NotificationReconciler{
-			Client:   mgr.GetClient(),
-			Log:      ctrl.Log.WithName("controllers").WithName("Notification"),
-			Scheme:   mgr.GetScheme(),
-			Gvk:      gvk,
-			Provider: _provider,
-			Resource: _provider.ResourcesMap["dynatrace_notification"],
-			TypeName: "dynatrace_notification",
+			Client:       mgr.GetClient(),
+			Log:          ctrl.Log.WithName("controllers").WithName("Notification"),
+			Scheme:       mgr.GetScheme(),
+			Recorder:     mgr.GetEventRecorderFor("provider-dynatrace-controller"),
+			Gvk:          gvk,
+			Provider:     _provider,
+			Resource:     _provider.ResourcesMap["dynatrace_notification"],
+			TypeName:     "dynatrace_notification",
+			ResyncPeriod: resyncPeriod,
 		}).SetupWithManager(ctx, mgr, auditor, restrictToNamespace); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "Notification")
 			return err
@@ -712,30 +995,66 @@ func SetupManager(ctx context.Context, mgr manager.Manager, gvk schema.GroupVers
 		Kind:    "Naming",
 	}:
 		if err := (&controllersprocessgroup.NamingReconciler{
-			Client:   mgr.GetClient(),
-			Log:      ctrl.Log.WithName("controllers").WithName("Naming"),
-			Scheme:   mgr.GetScheme(),
-			Gvk:      gvk,
-			Provider: _provider,
-			Resource: _provider.ResourcesMap["dynatrace_processgroup_naming"],
-			TypeName: "dynatrace_processgroup_naming",
+			Client:       mgr.GetClient(),
+			Log:          ctrl.Log.WithName("controllers").WithName("Naming"),
+			Scheme:       mgr.GetScheme(),
+			Recorder:     mgr.GetEventRecorderFor("provider-dynatrace-controller"),
+			Gvk:          gvk,
+			Provider:     _provider,
+			Resource:     _provider.ResourcesMap["dynatrace_processgroup_naming"],
+			TypeName:     "dynatrace_processgroup_naming",
+			ResyncPeriod: resyncPeriod,
 		}).SetupWithManager(ctx, mgr, auditor, restrictToNamespace); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "Naming")
 			return err
 		}
+	case schema.GroupVersionKind{
+		Group:   "provider.dynatrace.kubeform.com",
+		Version: "v1alpha1",
+		Kind:    "ClusterProviderConfig",
+	}:
+		if err := (&controllersproviderconfig.ProviderConfigReconciler{
+			Client:       mgr.GetClient(),
+			Log:          ctrl.Log.WithName("controllers").WithName("ClusterProviderConfig"),
+			Scheme:       mgr.GetScheme(),
+			Recorder:     mgr.GetEventRecorderFor("provider-dynatrace-controller"),
+			Gvk:          gvk,
+			ResyncPeriod: resyncPeriod,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "ClusterProviderConfig")
+			return err
+		}
+	case schema.GroupVersionKind{
+		Group:   "provider.dynatrace.kubeform.com",
+		Version: "v1alpha1",
+		Kind:    "ProviderConfig",
+	}:
+		if err := (&controllersproviderconfig.ProviderConfigReconciler{
+			Client:       mgr.GetClient(),
+			Log:          ctrl.Log.WithName("controllers").WithName("ProviderConfig"),
+			Scheme:       mgr.GetScheme(),
+			Recorder:     mgr.GetEventRecorderFor("provider-dynatrace-controller"),
+			Gvk:          gvk,
+			ResyncPeriod: resyncPeriod,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "ProviderConfig")
+			return err
+		}
 	case schema.GroupVersionKind{
 		Group:   "request.dynatrace.kubeform.com",
 		Version: "v1alpha1",
 		Kind:    "Attribute",
 	}:
 		if err := (&controllersrequest.AttributeReconciler{
-			Client:   mgr.GetClient(),
-			Log:      ctrl.Log.WithName("controllers").WithName("Attribute"),
-			Scheme:   mgr.GetScheme(),
-			Gvk:      gvk,
-			Provider: _provider,
-			Resource: _provider.ResourcesMap["dynatrace_request_attribute"],
-			TypeName: "dynatrace_request_attribute",
+			Client:       mgr.GetClient(),
+			Log:          ctrl.Log.WithName("controllers").WithName("Attribute"),
+			Scheme:       mgr.GetScheme(),
+			Recorder:     mgr.GetEventRecorderFor("provider-dynatrace-controller"),
+			Gvk:          gvk,
+			Provider:     _provider,
+			Resource:     _provider.ResourcesMap["dynatrace_request_attribute"],
+			TypeName:     "dynatrace_request_attribute",
+			ResyncPeriod: resyncPeriod,
 		}).SetupWithManager(ctx, mgr, auditor, restrictToNamespace); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "Attribute")
 			return err
@@ -746,13 +1065,15 @@ func SetupManager(ctx context.Context, mgr manager.Manager, gvk schema.GroupVers
 		Kind:    "Naming",
 	}:
 		if err := (&controllersrequest.NamingReconciler{
-			Client:   mgr.GetClient(),
-			Log:      ctrl.Log.WithName("controllers").WithName("Naming"),
-			Scheme:   mgr.GetScheme(),
-			Gvk:      gvk,
-			Provider: _provider,
-			Resource: _provider.ResourcesMap["dynatrace_request_naming"],
-			TypeName: "dynatrace_request_naming",
+			Client:       mgr.GetClient(),
+			Log:          ctrl.Log.WithName("controllers").WithName("Naming"),
+			Scheme:       mgr.GetScheme(),
+			Recorder:     mgr.GetEventRecorderFor("provider-dynatrace-controller"),
+			Gvk:          gvk,
+			Provider:     _provider,
+			Resource:     _provider.ResourcesMap["dynatrace_request_naming"],
+			TypeName:     "dynatrace_request_naming",
+			ResyncPeriod: resyncPeriod,
 		}).SetupWithManager(ctx, mgr, auditor, restrictToNamespace); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "Naming")
 			return err
@@ -763,13 +1084,15 @@ func SetupManager(ctx context.Context, mgr manager.Manager, gvk schema.GroupVers
 		Kind:    "Namings",
 	}:
 		if err := (&controllersrequest.NamingsReconciler{
-			Client:   mgr.GetClient(),
-			Log:      ctrl.Log.WithName("controllers").WithName("Namings"),
-			Scheme:   mgr.GetScheme(),
-			Gvk:      gvk,
-			Provider: _provider,
-			Resource: _provider.ResourcesMap["dynatrace_request_namings"],
-			TypeName: "dynatrace_request_namings",
+			Client:       mgr.GetClient(),
+			Log:          ctrl.Log.WithName("controllers").WithName("Namings"),
+			Scheme:       mgr.GetScheme(),
+			Recorder:     mgr.GetEventRecorderFor("provider-dynatrace-controller"),
+			Gvk:          gvk,
+			Provider:     _provider,
+			Resource:     _provider.ResourcesMap["dynatrace_request_namings"],
+			TypeName:     "dynatrace_request_namings",
+			ResyncPeriod: resyncPeriod,
 		}).SetupWithManager(ctx, mgr, auditor, restrictToNamespace); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "Namings")
 			return err
@@ -780,13 +1103,15 @@ func SetupManager(ctx context.Context, mgr manager.Manager, gvk schema.GroupVers
 		Kind:    "Attributes",
 	}:
 		if err := (&controllersresource.AttributesReconciler{
-			Client:   mgr.GetClient(),
-			Log:      ctrl.Log.WithName("controllers").WithName("Attributes"),
-			Scheme:   mgr.GetScheme(),
-			Gvk:      gvk,
-			Provider: _provider,
-			Resource: _provider.ResourcesMap["dynatrace_resource_attributes"],
-			TypeName: "dynatrace_resource_attributes",
+			Client:       mgr.GetClient(),
+			Log:          ctrl.Log.WithName("controllers").WithName("Attributes"),
+			Scheme:       mgr.GetScheme(),
+			Recorder:     mgr.GetEventRecorderFor("provider-dynatrace-controller"),
+			Gvk:          gvk,
+			Provider:     _provider,
+			Resource:     _provider.ResourcesMap["dynatrace_resource_attributes"],
+			TypeName:     "dynatrace_resource_attributes",
+			ResyncPeriod: resyncPeriod,
 		}).SetupWithManager(ctx, mgr, auditor, restrictToNamespace); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "Attributes")
 			return err
@@ -797,13 +1122,15 @@ func SetupManager(ctx context.Context, mgr manager.Manager, gvk schema.GroupVers
 		Kind:    "Anomalies",
 	}:
 		if err := (&controllersservice.AnomaliesReconciler{
-			Client:   mgr.GetClient(),
-			Log:      ctrl.Log.WithName("controllers").WithName("Anomalies"),
-			Scheme:   mgr.GetScheme(),
-			Gvk:      gvk,
-			Provider: _provider,
-			Resource: _provider.ResourcesMap["dynatrace_service_anomalies"],
-			TypeName: "dynatrace_service_anomalies",
+			Client:       mgr.GetClient(),
+			Log:          ctrl.Log.WithName("controllers").WithName("Anomalies"),
+			Scheme:       mgr.GetScheme(),
+			Recorder:     mgr.GetEventRecorderFor("provider-dynatrace-controller"),
+			Gvk:          gvk,
+			Provider:     _provider,
+			Resource:     _provider.ResourcesMap["dynatrace_service_anomalies"],
+			TypeName:     "dynatrace_service_anomalies",
+			ResyncPeriod: resyncPeriod,
 		}).SetupWithManager(ctx, mgr, auditor, restrictToNamespace); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "Anomalies")
 			return err
@@ -814,13 +1141,15 @@ func SetupManager(ctx context.Context, mgr manager.Manager, gvk schema.GroupVers
 		Kind:    "Naming",
 	}:
 		if err := (&controllersservice.NamingReconciler{
-			Client:   mgr.GetClient(),
-			Log:      ctrl.Log.WithName("controllers").WithName("Naming"),
-			Scheme:   mgr.GetScheme(),
-			Gvk:      gvk,
-			Provider: _provider,
-			Resource: _provider.ResourcesMap["dynatrace_service_naming"],
-			TypeName: "dynatrace_service_naming",
+			Client:       mgr.GetClient(),
+			Log:          ctrl.Log.WithName("controllers").WithName("Naming"),
+			Scheme:       mgr.GetScheme(),
+			Recorder:     mgr.GetEventRecorderFor("provider-dynatrace-controller"),
+			Gvk:          gvk,
+			Provider:     _provider,
+			Resource:     _provider.ResourcesMap["dynatrace_service_naming"],
+			TypeName:     "dynatrace_service_naming",
+			ResyncPeriod: resyncPeriod,
 		}).SetupWithManager(ctx, mgr, auditor, restrictToNamespace); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "Naming")
 			return err
@@ -831,13 +1160,15 @@ func SetupManager(ctx context.Context, mgr manager.Manager, gvk schema.GroupVers
 		Kind:    "Slo",
 	}:
 		if err := (&controllersslo.SloReconciler{
-			Client:   mgr.GetClient(),
-			Log:      ctrl.Log.WithName("controllers").WithName("Slo"),
-			Scheme:   mgr.GetScheme(),
-			Gvk:      gvk,
-			Provider: _provider,
-			Resource: _provider.ResourcesMap["dynatrace_slo"],
-			TypeName: "dynatrace_slo",
+			Client:       mgr.GetClient(),
+			Log:          ctrl.Log.WithName("controllers").WithName("Slo"),
+			Scheme:       mgr.GetScheme(),
+			Recorder:     mgr.GetEventRecorderFor("provider-dynatrace-controller"),
+			Gvk:          gvk,
+			Provider:     _provider,
+			Resource:     _provider.ResourcesMap["dynatrace_slo"],
+			TypeName:     "dynatrace_slo",
+			ResyncPeriod: resyncPeriod,
 		}).SetupWithManager(ctx, mgr, auditor, restrictToNamespace); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "Slo")
 			return err
@@ -848,13 +1179,15 @@ func SetupManager(ctx context.Context, mgr manager.Manager, gvk schema.GroupVers
 		Kind:    "Attribute",
 	}:
 		if err := (&controllersspan.AttributeReconciler{
-			Client:   mgr.GetClient(),
-			Log:      ctrl.Log.WithName("controllers").WithName("Attribute"),
-			Scheme:   mgr.GetScheme(),
-			Gvk:      gvk,
-			Provider: _provider,
-			Resource: _provider.ResourcesMap["dynatrace_span_attribute"],
-			TypeName: "dynatrace_span_attribute",
+			Client:       mgr.GetClient(),
+			Log:          ctrl.Log.WithName("controllers").WithName("Attribute"),
+			Scheme:       mgr.GetScheme(),
+			Recorder:     mgr.GetEventRecorderFor("provider-dynatrace-controller"),
+			Gvk:          gvk,
+			Provider:     _provider,
+			Resource:     _provider.ResourcesMap["dynatrace_span_attribute"],
+			TypeName:     "dynatrace_span_attribute",
+			ResyncPeriod: resyncPeriod,
 		}).SetupWithManager(ctx, mgr, auditor, restrictToNamespace); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "Attribute")
 			return err
@@ -865,13 +1198,15 @@ func SetupManager(ctx context.Context, mgr manager.Manager, gvk schema.GroupVers
 		Kind:    "CaptureRule",
 	}:
 		if err := (&controllersspan.CaptureRuleReconciler{
-			Client:   mgr.GetClient(),
-			Log:      ctrl.Log.WithName("controllers").WithName("CaptureRule"),
-			Scheme:   mgr.GetScheme(),
-			Gvk:      gvk,
-			Provider: _provider,
-			Resource: _provider.ResourcesMap["dynatrace_span_capture_rule"],
-			TypeName: "dynatrace_span_capture_rule",
+			Client:       mgr.GetClient(),
+			Log:          ctrl.Log.WithName("controllers").WithName("CaptureRule"),
+			Scheme:       mgr.GetScheme(),
+			Recorder:     mgr.GetEventRecorderFor("provider-dynatrace-controller"),
+			Gvk:          gvk,
+			Provider:     _provider,
+			Resource:     _provider.ResourcesMap["dynatrace_span_capture_rule"],
+			TypeName:     "dynatrace_span_capture_rule",
+			ResyncPeriod: resyncPeriod,
 		}).SetupWithManager(ctx, mgr, auditor, restrictToNamespace); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "CaptureRule")
 			return err
@@ -882,13 +1217,15 @@ func SetupManager(ctx context.Context, mgr manager.Manager, gvk schema.GroupVers
 		Kind:    "ContextPropagation",
 	}:
 		if err := (&controllersspan.ContextPropagationReconciler{
-			Client:   mgr.GetClient(),
-			Log:      ctrl.Log.WithName("controllers").WithName("ContextPropagation"),
-			Scheme:   mgr.GetScheme(),
-			Gvk:      gvk,
-			Provider: _provider,
-			Resource: _provider.ResourcesMap["dynatrace_span_context_propagation"],
-			TypeName: "dynatrace_span_context_propagation",
+			Client:       mgr.GetClient(),
+			Log:          ctrl.Log.WithName("controllers").WithName("ContextPropagation"),
+			Scheme:       mgr.GetScheme(),
+			Recorder:     mgr.GetEventRecorderFor("provider-dynatrace-controller"),
+			Gvk:          gvk,
+			Provider:     _provider,
+			Resource:     _provider.ResourcesMap["dynatrace_span_context_propagation"],
+			TypeName:     "dynatrace_span_context_propagation",
+			ResyncPeriod: resyncPeriod,
 		}).SetupWithManager(ctx, mgr, auditor, restrictToNamespace); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "ContextPropagation")
 			return err
@@ -899,13 +1236,15 @@ func SetupManager(ctx context.Context, mgr manager.Manager, gvk schema.GroupVers
 		Kind:    "EntryPoint",
 	}:
 		if err := (&controllersspan.EntryPointReconciler{
-			Client:   mgr.GetClient(),
-			Log:      ctrl.Log.WithName("controllers").WithName("EntryPoint"),
-			Scheme:   mgr.GetScheme(),
-			Gvk:      gvk,
-			Provider: _provider,
-			Resource: _provider.ResourcesMap["dynatrace_span_entry_point"],
-			TypeName: "dynatrace_span_entry_point",
+			Client:       mgr.GetClient(),
+			Log:          ctrl.Log.WithName("controllers").WithName("EntryPoint"),
+			Scheme:       mgr.GetScheme(),
+			Recorder:     mgr.GetEventRecorderFor("provider-dynatrace-controller"),
+			Gvk:          gvk,
+			Provider:     _provider,
+			Resource:     _provider.ResourcesMap["dynatrace_span_entry_point"],
+			TypeName:     "dynatrace_span_entry_point",
+			ResyncPeriod: resyncPeriod,
 		}).SetupWithManager(ctx, mgr, auditor, restrictToNamespace); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "EntryPoint")
 			return err
@@ -916,13 +1255,15 @@ func SetupManager(ctx context.Context, mgr manager.Manager, gvk schema.GroupVers
 		Kind:    "User",
 	}:
 		if err := (&controllersuser.UserReconciler{
-			Client:   mgr.GetClient(),
-			Log:      ctrl.Log.WithName("controllers").WithName("User"),
-			Scheme:   mgr.GetScheme(),
-			Gvk:      gvk,
-			Provider: _provider,
-			Resource: _provider.ResourcesMap["dynatrace_user"],
-			TypeName: "dynatrace_user",
+			Client:       mgr.GetClient(),
+			Log:          ctrl.Log.WithName("controllers").WithName("User"),
+			Scheme:       mgr.GetScheme(),
+			Recorder:     mgr.GetEventRecorderFor("provider-dynatrace-controller"),
+			Gvk:          gvk,
+			Provider:     _provider,
+			Resource:     _provider.ResourcesMap["dynatrace_user"],
+			TypeName:     "dynatrace_user",
+			ResyncPeriod: resyncPeriod,
 		}).SetupWithManager(ctx, mgr, auditor, restrictToNamespace); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "User")
 			return err
@@ -933,13 +1274,15 @@ func SetupManager(ctx context.Context, mgr manager.Manager, gvk schema.GroupVers
 		Kind:    "Group",
 	}:
 		if err := (&controllersuser.GroupReconciler{
-			Client:   mgr.GetClient(),
-			Log:      ctrl.Log.WithName("controllers").WithName("Group"),
-			Scheme:   mgr.GetScheme(),
-			Gvk:      gvk,
-			Provider: _provider,
-			Resource: _provider.ResourcesMap["dynatrace_user_group"],
-			TypeName: "dynatrace_user_group",
+			Client:       mgr.GetClient(),
+			Log:          ctrl.Log.WithName("controllers").WithName("Group"),
+			Scheme:       mgr.GetScheme(),
+			Recorder:     mgr.GetEventRecorderFor("provider-dynatrace-controller"),
+			Gvk:          gvk,
+			Provider:     _provider,
+			Resource:     _provider.ResourcesMap["dynatrace_user_group"],
+			TypeName:     "dynatrace_user_group",
+			ResyncPeriod: resyncPeriod,
 		}).SetupWithManager(ctx, mgr, auditor, restrictToNamespace); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "Group")
 			return err
@@ -950,13 +1293,15 @@ func SetupManager(ctx context.Context, mgr manager.Manager, gvk schema.GroupVers
 		Kind:    "Application",
 	}:
 		if err := (&controllersweb.ApplicationReconciler{
-			Client:   mgr.GetClient(),
-			Log:      ctrl.Log.WithName("controllers").WithName("Application"),
-			Scheme:   mgr.GetScheme(),
-			Gvk:      gvk,
-			Provider: _provider,
-			Resource: _provider.ResourcesMap["dynatrace_web_application"],
-			TypeName: "dynatrace_web_application",
+			Client:       mgr.GetClient(),
+			Log:          ctrl.Log.WithName("controllers").WithName("Application"),
+			Scheme:       mgr.GetScheme(),
+			Recorder:     mgr.GetEventRecorderFor("provider-dynatrace-controller"),
+			Gvk:          gvk,
+			Provider:     _provider,
+			Resource:     _provider.ResourcesMap["dynatrace_web_application"],
+			TypeName:     "dynatrace_web_application",
+			ResyncPeriod: resyncPeriod,
 		}).SetupWithManager(ctx, mgr, auditor, restrictToNamespace); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "Application")
 			return err
@@ -976,7 +1321,15 @@ func SetupWebhook(mgr manager.Manager, gvk schema.GroupVersionKind) error {
 		Version: "v1alpha1",
 		Kind:    "Profile",
 	}:
-		if err := (&alertingv1alpha1.Profile{}).SetupWebhookWithManager(mgr); err != nil {
+		if err := (&controllers.ResourceValidator{
+			Client:    mgr.GetClient(),
+			Gvk:       gvk,
+			Provider:  _provider,
+			Resource:  _provider.ResourcesMap["dynatrace_alerting_profile"],
+			TypeName:  "dynatrace_alerting_profile",
+			JsonIt:    controllers.GetJSONItr(alertingv1alpha1.GetEncoder(), alertingv1alpha1.GetDecoder()),
+			Validator: &alertingv1alpha1.Profile{},
+		}).SetupWebhookWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "Profile")
 			return err
 		}
@@ -985,7 +1338,15 @@ func SetupWebhook(mgr manager.Manager, gvk schema.GroupVersionKind) error {
 		Version: "v1alpha1",
 		Kind:    "Anomalies",
 	}:
-		if err := (&applicationv1alpha1.Anomalies{}).SetupWebhookWithManager(mgr); err != nil {
+		if err := (&controllers.ResourceValidator{
+			Client:    mgr.GetClient(),
+			Gvk:       gvk,
+			Provider:  _provider,
+			Resource:  _provider.ResourcesMap["dynatrace_application_anomalies"],
+			TypeName:  "dynatrace_application_anomalies",
+			JsonIt:    controllers.GetJSONItr(applicationv1alpha1.GetEncoder(), applicationv1alpha1.GetDecoder()),
+			Validator: &applicationv1alpha1.Anomalies{},
+		}).SetupWebhookWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "Anomalies")
 			return err
 		}
@@ -994,7 +1355,15 @@ func SetupWebhook(mgr manager.Manager, gvk schema.GroupVersionKind) error {
 		Version: "v1alpha1",
 		Kind:    "DataPrivacy",
 	}:
-		if err := (&applicationv1alpha1.DataPrivacy{}).SetupWebhookWithManager(mgr); err != nil {
+		if err := (&controllers.ResourceValidator{
+			Client:    mgr.GetClient(),
+			Gvk:       gvk,
+			Provider:  _provider,
+			Resource:  _provider.ResourcesMap["dynatrace_application_data_privacy"],
+			TypeName:  "dynatrace_application_data_privacy",
+			JsonIt:    controllers.GetJSONItr(applicationv1alpha1.GetEncoder(), applicationv1alpha1.GetDecoder()),
+			Validator: &applicationv1alpha1.DataPrivacy{},
+		}).SetupWebhookWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "DataPrivacy")
 			return err
 		}
@@ -1003,7 +1372,15 @@ func SetupWebhook(mgr manager.Manager, gvk schema.GroupVersionKind) error {
 		Version: "v1alpha1",
 		Kind:    "ErrorRules",
 	}:
-		if err := (&applicationv1alpha1.ErrorRules{}).SetupWebhookWithManager(mgr); err != nil {
+		if err := (&controllers.ResourceValidator{
+			Client:    mgr.GetClient(),
+			Gvk:       gvk,
+			Provider:  _provider,
+			Resource:  _provider.ResourcesMap["dynatrace_application_error_rules"],
+			TypeName:  "dynatrace_application_error_rules",
+			JsonIt:    controllers.GetJSONItr(applicationv1alpha1.GetEncoder(), applicationv1alpha1.GetDecoder()),
+			Validator: &applicationv1alpha1.ErrorRules{},
+		}).SetupWebhookWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "ErrorRules")
 			return err
 		}
@@ -1012,7 +1389,15 @@ func SetupWebhook(mgr manager.Manager, gvk schema.GroupVersionKind) error {
 		Version: "v1alpha1",
 		Kind:    "Autotag",
 	}:
-		if err := (&autotagv1alpha1.Autotag{}).SetupWebhookWithManager(mgr); err != nil {
+		if err := (&controllers.ResourceValidator{
+			Client:    mgr.GetClient(),
+			Gvk:       gvk,
+			Provider:  _provider,
+			Resource:  _provider.ResourcesMap["dynatrace_autotag"],
+			TypeName:  "dynatrace_autotag",
+			JsonIt:    controllers.GetJSONItr(autotagv1alpha1.GetEncoder(), autotagv1alpha1.GetDecoder()),
+			Validator: &autotagv1alpha1.Autotag{},
+		}).SetupWebhookWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "Autotag")
 			return err
 		}
@@ -1021,7 +1406,15 @@ func SetupWebhook(mgr manager.Manager, gvk schema.GroupVersionKind) error {
 		Version: "v1alpha1",
 		Kind:    "Credentials",
 	}:
-		if err := (&awsv1alpha1.Credentials{}).SetupWebhookWithManager(mgr); err != nil {
+		if err := (&controllers.ResourceValidator{
+			Client:    mgr.GetClient(),
+			Gvk:       gvk,
+			Provider:  _provider,
+			Resource:  _provider.ResourcesMap["dynatrace_aws_credentials"],
+			TypeName:  "dynatrace_aws_credentials",
+			JsonIt:    controllers.GetJSONItr(awsv1alpha1.GetEncoder(), awsv1alpha1.GetDecoder()),
+			Validator: &awsv1alpha1.Credentials{},
+		}).SetupWebhookWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "Credentials")
 			return err
 		}
@@ -1030,7 +1423,15 @@ func SetupWebhook(mgr manager.Manager, gvk schema.GroupVersionKind) error {
 		Version: "v1alpha1",
 		Kind:    "Credentials",
 	}:
-		if err := (&azurev1alpha1.Credentials{}).SetupWebhookWithManager(mgr); err != nil {
+		if err := (&controllers.ResourceValidator{
+			Client:    mgr.GetClient(),
+			Gvk:       gvk,
+			Provider:  _provider,
+			Resource:  _provider.ResourcesMap["dynatrace_azure_credentials"],
+			TypeName:  "dynatrace_azure_credentials",
+			JsonIt:    controllers.GetJSONItr(azurev1alpha1.GetEncoder(), azurev1alpha1.GetDecoder()),
+			Validator: &azurev1alpha1.Credentials{},
+		}).SetupWebhookWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "Credentials")
 			return err
 		}
@@ -1039,7 +1440,15 @@ func SetupWebhook(mgr manager.Manager, gvk schema.GroupVersionKind) error {
 		Version: "v1alpha1",
 		Kind:    "Monitor",
 	}:
-		if err := (&browserv1alpha1.Monitor{}).SetupWebhookWithManager(mgr); err != nil {
+		if err := (&controllers.ResourceValidator{
+			Client:    mgr.GetClient(),
+			Gvk:       gvk,
+			Provider:  _provider,
+			Resource:  _provider.ResourcesMap["dynatrace_browser_monitor"],
+			TypeName:  "dynatrace_browser_monitor",
+			JsonIt:    controllers.GetJSONItr(browserv1alpha1.GetEncoder(), browserv1alpha1.GetDecoder()),
+			Validator: &browserv1alpha1.Monitor{},
+		}).SetupWebhookWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "Monitor")
 			return err
 		}
@@ -1048,7 +1457,15 @@ func SetupWebhook(mgr manager.Manager, gvk schema.GroupVersionKind) error {
 		Version: "v1alpha1",
 		Kind:    "ServiceMetric",
 	}:
-		if err := (&calculatedv1alpha1.ServiceMetric{}).SetupWebhookWithManager(mgr); err != nil {
+		if err := (&controllers.ResourceValidator{
+			Client:    mgr.GetClient(),
+			Gvk:       gvk,
+			Provider:  _provider,
+			Resource:  _provider.ResourcesMap["dynatrace_calculated_service_metric"],
+			TypeName:  "dynatrace_calculated_service_metric",
+			JsonIt:    controllers.GetJSONItr(calculatedv1alpha1.GetEncoder(), calculatedv1alpha1.GetDecoder()),
+			Validator: &calculatedv1alpha1.ServiceMetric{},
+		}).SetupWebhookWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "ServiceMetric")
 			return err
 		}
@@ -1057,7 +1474,15 @@ func SetupWebhook(mgr manager.Manager, gvk schema.GroupVersionKind) error {
 		Version: "v1alpha1",
 		Kind:    "Anomalies",
 	}:
-		if err := (&customv1alpha1.Anomalies{}).SetupWebhookWithManager(mgr); err != nil {
+		if err := (&controllers.ResourceValidator{
+			Client:    mgr.GetClient(),
+			Gvk:       gvk,
+			Provider:  _provider,
+			Resource:  _provider.ResourcesMap["dynatrace_custom_anomalies"],
+			TypeName:  "dynatrace_custom_anomalies",
+			JsonIt:    controllers.GetJSONItr(customv1alpha1.GetEncoder(), customv1alpha1.GetDecoder()),
+			Validator: &customv1alpha1.Anomalies{},
+		}).SetupWebhookWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "Anomalies")
 			return err
 		}
@@ -1066,7 +1491,15 @@ func SetupWebhook(mgr manager.Manager, gvk schema.GroupVersionKind) error {
 		Version: "v1alpha1",
 		Kind:    "Service",
 	}:
-		if err := (&customv1alpha1.Service{}).SetupWebhookWithManager(mgr); err != nil {
+		if err := (&controllers.ResourceValidator{
+			Client:    mgr.GetClient(),
+			Gvk:       gvk,
+			Provider:  _provider,
+			Resource:  _provider.ResourcesMap["dynatrace_custom_service"],
+			TypeName:  "dynatrace_custom_service",
+			JsonIt:    controllers.GetJSONItr(customv1alpha1.GetEncoder(), customv1alpha1.GetDecoder()),
+			Validator: &customv1alpha1.Service{},
+		}).SetupWebhookWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "Service")
 			return err
 		}
@@ -1075,7 +1508,15 @@ func SetupWebhook(mgr manager.Manager, gvk schema.GroupVersionKind) error {
 		Version: "v1alpha1",
 		Kind:    "Dashboard",
 	}:
-		if err := (&dashboardv1alpha1.Dashboard{}).SetupWebhookWithManager(mgr); err != nil {
+		if err := (&controllers.ResourceValidator{
+			Client:    mgr.GetClient(),
+			Gvk:       gvk,
+			Provider:  _provider,
+			Resource:  _provider.ResourcesMap["dynatrace_dashboard"],
+			TypeName:  "dynatrace_dashboard",
+			JsonIt:    controllers.GetJSONItr(dashboardv1alpha1.GetEncoder(), dashboardv1alpha1.GetDecoder()),
+			Validator: &dashboardv1alpha1.Dashboard{},
+		}).SetupWebhookWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "Dashboard")
 			return err
 		}
@@ -1084,7 +1525,15 @@ func SetupWebhook(mgr manager.Manager, gvk schema.GroupVersionKind) error {
 		Version: "v1alpha1",
 		Kind:    "Sharing",
 	}:
-		if err := (&dashboardv1alpha1.Sharing{}).SetupWebhookWithManager(mgr); err != nil {
+		if err := (&controllers.ResourceValidator{
+			Client:    mgr.GetClient(),
+			Gvk:       gvk,
+			Provider:  _provider,
+			Resource:  _provider.ResourcesMap["dynatrace_dashboard_sharing"],
+			TypeName:  "dynatrace_dashboard_sharing",
+			JsonIt:    controllers.GetJSONItr(dashboardv1alpha1.GetEncoder(), dashboardv1alpha1.GetDecoder()),
+			Validator: &dashboardv1alpha1.Sharing{},
+		}).SetupWebhookWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "Sharing")
 			return err
 		}
@@ -1093,7 +1542,15 @@ func SetupWebhook(mgr manager.Manager, gvk schema.GroupVersionKind) error {
 		Version: "v1alpha1",
 		Kind:    "Anomalies",
 	}:
-		if err := (&databasev1alpha1.Anomalies{}).SetupWebhookWithManager(mgr); err != nil {
+		if err := (&controllers.ResourceValidator{
+			Client:    mgr.GetClient(),
+			Gvk:       gvk,
+			Provider:  _provider,
+			Resource:  _provider.ResourcesMap["dynatrace_database_anomalies"],
+			TypeName:  "dynatrace_database_anomalies",
+			JsonIt:    controllers.GetJSONItr(databasev1alpha1.GetEncoder(), databasev1alpha1.GetDecoder()),
+			Validator: &databasev1alpha1.Anomalies{},
+		}).SetupWebhookWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "Anomalies")
 			return err
 		}
@@ -1102,7 +1559,15 @@ func SetupWebhook(mgr manager.Manager, gvk schema.GroupVersionKind) error {
 		Version: "v1alpha1",
 		Kind:    "Anomalies",
 	}:
-		if err := (&diskv1alpha1.Anomalies{}).SetupWebhookWithManager(mgr); err != nil {
+		if err := (&controllers.ResourceValidator{
+			Client:    mgr.GetClient(),
+			Gvk:       gvk,
+			Provider:  _provider,
+			Resource:  _provider.ResourcesMap["dynatrace_disk_anomalies"],
+			TypeName:  "dynatrace_disk_anomalies",
+			JsonIt:    controllers.GetJSONItr(diskv1alpha1.GetEncoder(), diskv1alpha1.GetDecoder()),
+			Validator: &diskv1alpha1.Anomalies{},
+		}).SetupWebhookWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "Anomalies")
 			return err
 		}
@@ -1111,7 +1576,15 @@ func SetupWebhook(mgr manager.Manager, gvk schema.GroupVersionKind) error {
 		Version: "v1alpha1",
 		Kind:    "Environment",
 	}:
-		if err := (&environmentv1alpha1.Environment{}).SetupWebhookWithManager(mgr); err != nil {
+		if err := (&controllers.ResourceValidator{
+			Client:    mgr.GetClient(),
+			Gvk:       gvk,
+			Provider:  _provider,
+			Resource:  _provider.ResourcesMap["dynatrace_environment"],
+			TypeName:  "dynatrace_environment",
+			JsonIt:    controllers.GetJSONItr(environmentv1alpha1.GetEncoder(), environmentv1alpha1.GetDecoder()),
+			Validator: &environmentv1alpha1.Environment{},
+		}).SetupWebhookWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "Environment")
 			return err
 		}
@@ -1120,7 +1593,15 @@ func SetupWebhook(mgr manager.Manager, gvk schema.GroupVersionKind) error {
 		Version: "v1alpha1",
 		Kind:    "Anomalies",
 	}:
-		if err := (&hostv1alpha1.Anomalies{}).SetupWebhookWithManager(mgr); err != nil {
+		if err := (&controllers.ResourceValidator{
+			Client:    mgr.GetClient(),
+			Gvk:       gvk,
+			Provider:  _provider,
+			Resource:  _provider.ResourcesMap["dynatrace_host_anomalies"],
+			TypeName:  "dynatrace_host_anomalies",
+			JsonIt:    controllers.GetJSONItr(hostv1alpha1.GetEncoder(), hostv1alpha1.GetDecoder()),
+			Validator: &hostv1alpha1.Anomalies{},
+		}).SetupWebhookWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "Anomalies")
 			return err
 		}
@@ -1129,7 +1610,15 @@ func SetupWebhook(mgr manager.Manager, gvk schema.GroupVersionKind) error {
 		Version: "v1alpha1",
 		Kind:    "Naming",
 	}:
-		if err := (&hostv1alpha1.Naming{}).SetupWebhookWithManager(mgr); err != nil {
+		if err := (&controllers.ResourceValidator{
+			Client:    mgr.GetClient(),
+			Gvk:       gvk,
+			Provider:  _provider,
+			Resource:  _provider.ResourcesMap["dynatrace_host_naming"],
+			TypeName:  "dynatrace_host_naming",
+			JsonIt:    controllers.GetJSONItr(hostv1alpha1.GetEncoder(), hostv1alpha1.GetDecoder()),
+			Validator: &hostv1alpha1.Naming{},
+		}).SetupWebhookWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "Naming")
 			return err
 		}
@@ -1138,7 +1627,15 @@ func SetupWebhook(mgr manager.Manager, gvk schema.GroupVersionKind) error {
 		Version: "v1alpha1",
 		Kind:    "Monitor",
 	}:
-		if err := (&httpv1alpha1.Monitor{}).SetupWebhookWithManager(mgr); err != nil {
+		if err := (&controllers.ResourceValidator{
+			Client:    mgr.GetClient(),
+			Gvk:       gvk,
+			Provider:  _provider,
+			Resource:  _provider.ResourcesMap["dynatrace_http_monitor"],
+			TypeName:  "dynatrace_http_monitor",
+			JsonIt:    controllers.GetJSONItr(httpv1alpha1.GetEncoder(), httpv1alpha1.GetDecoder()),
+			Validator: &httpv1alpha1.Monitor{},
+		}).SetupWebhookWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "Monitor")
 			return err
 		}
@@ -1147,7 +1644,15 @@ func SetupWebhook(mgr manager.Manager, gvk schema.GroupVersionKind) error {
 		Version: "v1alpha1",
 		Kind:    "Credentials",
 	}:
-		if err := (&k8sv1alpha1.Credentials{}).SetupWebhookWithManager(mgr); err != nil {
+		if err := (&controllers.ResourceValidator{
+			Client:    mgr.GetClient(),
+			Gvk:       gvk,
+			Provider:  _provider,
+			Resource:  _provider.ResourcesMap["dynatrace_k8s_credentials"],
+			TypeName:  "dynatrace_k8s_credentials",
+			JsonIt:    controllers.GetJSONItr(k8sv1alpha1.GetEncoder(), k8sv1alpha1.GetDecoder()),
+			Validator: &k8sv1alpha1.Credentials{},
+		}).SetupWebhookWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "Credentials")
 			return err
 		}
@@ -1156,7 +1661,15 @@ func SetupWebhook(mgr manager.Manager, gvk schema.GroupVersionKind) error {
 		Version: "v1alpha1",
 		Kind:    "Requests",
 	}:
-		if err := (&keyv1alpha1.Requests{}).SetupWebhookWithManager(mgr); err != nil {
+		if err := (&controllers.ResourceValidator{
+			Client:    mgr.GetClient(),
+			Gvk:       gvk,
+			Provider:  _provider,
+			Resource:  _provider.ResourcesMap["dynatrace_key_requests"],
+			TypeName:  "dynatrace_key_requests",
+			JsonIt:    controllers.GetJSONItr(keyv1alpha1.GetEncoder(), keyv1alpha1.GetDecoder()),
+			Validator: &keyv1alpha1.Requests{},
+		}).SetupWebhookWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "Requests")
 			return err
 		}
@@ -1165,7 +1678,15 @@ func SetupWebhook(mgr manager.Manager, gvk schema.GroupVersionKind) error {
 		Version: "v1alpha1",
 		Kind:    "Window",
 	}:
-		if err := (&maintenancev1alpha1.Window{}).SetupWebhookWithManager(mgr); err != nil {
+		if err := (&controllers.ResourceValidator{
+			Client:    mgr.GetClient(),
+			Gvk:       gvk,
+			Provider:  _provider,
+			Resource:  _provider.ResourcesMap["dynatrace_maintenance_window"],
+			TypeName:  "dynatrace_maintenance_window",
+			JsonIt:    controllers.GetJSONItr(maintenancev1alpha1.GetEncoder(), maintenancev1alpha1.GetDecoder()),
+			Validator: &maintenancev1alpha1.Window{},
+		}).SetupWebhookWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "Window")
 			return err
 		}
@@ -1174,7 +1695,15 @@ func SetupWebhook(mgr manager.Manager, gvk schema.GroupVersionKind) error {
 		Version: "v1alpha1",
 		Kind:    "Zone",
 	}:
-		if err := (&managementv1alpha1.Zone{}).SetupWebhookWithManager(mgr); err != nil {
+		if err := (&controllers.ResourceValidator{
+			Client:    mgr.GetClient(),
+			Gvk:       gvk,
+			Provider:  _provider,
+			Resource:  _provider.ResourcesMap["dynatrace_management_zone"],
+			TypeName:  "dynatrace_management_zone",
+			JsonIt:    controllers.GetJSONItr(managementv1alpha1.GetEncoder(), managementv1alpha1.GetDecoder()),
+			Validator: &managementv1alpha1.Zone{},
+		}).SetupWebhookWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "Zone")
 			return err
 		}
@@ -1183,7 +1712,15 @@ func SetupWebhook(mgr manager.Manager, gvk schema.GroupVersionKind) error {
 		Version: "v1alpha1",
 		Kind:    "Application",
 	}:
-		if err := (&mobilev1alpha1.Application{}).SetupWebhookWithManager(mgr); err != nil {
+		if err := (&controllers.ResourceValidator{
+			Client:    mgr.GetClient(),
+			Gvk:       gvk,
+			Provider:  _provider,
+			Resource:  _provider.ResourcesMap["dynatrace_mobile_application"],
+			TypeName:  "dynatrace_mobile_application",
+			JsonIt:    controllers.GetJSONItr(mobilev1alpha1.GetEncoder(), mobilev1alpha1.GetDecoder()),
+			Validator: &mobilev1alpha1.Application{},
+		}).SetupWebhookWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "Application")
 			return err
 		}
@@ -1192,7 +1729,15 @@ func SetupWebhook(mgr manager.Manager, gvk schema.GroupVersionKind) error {
 		Version: "v1alpha1",
 		Kind:    "Notification",
 	}:
-		if err := (&notificationv1alpha1.Notification{}).SetupWebhookWithManager(mgr); err != nil {
+		if err := (&controllers.ResourceValidator{
+			Client:    mgr.GetClient(),
+			Gvk:       gvk,
+			Provider:  _provider,
+			Resource:  _provider.ResourcesMap["dynatrace_notification"],
+			TypeName:  "dynatrace_notification",
+			JsonIt:    controllers.GetJSONItr(notificationv1alpha1.GetEncoder(), notificationv1alpha1.GetDecoder()),
+			Validator: &notificationv1alpha1.Notification{},
+		}).SetupWebhookWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "Notification")
 			return err
 		}
@@ -1201,16 +1746,58 @@ func SetupWebhook(mgr manager.Manager, gvk schema.GroupVersionKind) error {
 		Version: "v1alpha1",
 		Kind:    "Naming",
 	}:
-		if err := (&processgroupv1alpha1.Naming{}).SetupWebhookWithManager(mgr); err != nil {
+		if err := (&controllers.ResourceValidator{
+			Client:    mgr.GetClient(),
+			Gvk:       gvk,
+			Provider:  _provider,
+			Resource:  _provider.ResourcesMap["dynatrace_processgroup_naming"],
+			TypeName:  "dynatrace_processgroup_naming",
+			JsonIt:    controllers.GetJSONItr(processgroupv1alpha1.GetEncoder(), processgroupv1alpha1.GetDecoder()),
+			Validator: &processgroupv1alpha1.Naming{},
+		}).SetupWebhookWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "Naming")
 			return err
 		}
+	case schema.GroupVersionKind{
+		Group:   "provider.dynatrace.kubeform.com",
+		Version: "v1alpha1",
+		Kind:    "ClusterProviderConfig",
+	}:
+		if err := (&controllersproviderconfig.ProviderConfigValidator{
+			Client:       mgr.GetClient(),
+			Gvk:          gvk,
+			CheckTimeout: time.Duration(webhookTimeoutSeconds) * time.Second / 2,
+		}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "ClusterProviderConfig")
+			return err
+		}
+	case schema.GroupVersionKind{
+		Group:   "provider.dynatrace.kubeform.com",
+		Version: "v1alpha1",
+		Kind:    "ProviderConfig",
+	}:
+		if err := (&controllersproviderconfig.ProviderConfigValidator{
+			Client:       mgr.GetClient(),
+			Gvk:          gvk,
+			CheckTimeout: time.Duration(webhookTimeoutSeconds) * time.Second / 2,
+		}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "ProviderConfig")
+			return err
+		}
 	case schema.GroupVersionKind{
 		Group:   "request.dynatrace.kubeform.com",
 		Version: "v1alpha1",
 		Kind:    "Attribute",
 	}:
-		if err := (&requestv1alpha1.Attribute{}).SetupWebhookWithManager(mgr); err != nil {
+		if err := (&controllers.ResourceValidator{
+			Client:    mgr.GetClient(),
+			Gvk:       gvk,
+			Provider:  _provider,
+			Resource:  _provider.ResourcesMap["dynatrace_request_attribute"],
+			TypeName:  "dynatrace_request_attribute",
+			JsonIt:    controllers.GetJSONItr(requestv1alpha1.GetEncoder(), requestv1alpha1.GetDecoder()),
+			Validator: &requestv1alpha1.Attribute{},
+		}).SetupWebhookWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "Attribute")
 			return err
 		}
@@ -1219,7 +1806,15 @@ func SetupWebhook(mgr manager.Manager, gvk schema.GroupVersionKind) error {
 		Version: "v1alpha1",
 		Kind:    "Naming",
 	}:
-		if err := (&requestv1alpha1.Naming{}).SetupWebhookWithManager(mgr); err != nil {
+		if err := (&controllers.ResourceValidator{
+			Client:    mgr.GetClient(),
+			Gvk:       gvk,
+			Provider:  _provider,
+			Resource:  _provider.ResourcesMap["dynatrace_request_naming"],
+			TypeName:  "dynatrace_request_naming",
+			JsonIt:    controllers.GetJSONItr(requestv1alpha1.GetEncoder(), requestv1alpha1.GetDecoder()),
+			Validator: &requestv1alpha1.Naming{},
+		}).SetupWebhookWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "Naming")
 			return err
 		}
@@ -1228,7 +1823,15 @@ func SetupWebhook(mgr manager.Manager, gvk schema.GroupVersionKind) error {
 		Version: "v1alpha1",
 		Kind:    "Namings",
 	}:
-		if err := (&requestv1alpha1.Namings{}).SetupWebhookWithManager(mgr); err != nil {
+		if err := (&controllers.ResourceValidator{
+			Client:    mgr.GetClient(),
+			Gvk:       gvk,
+			Provider:  _provider,
+			Resource:  _provider.ResourcesMap["dynatrace_request_namings"],
+			TypeName:  "dynatrace_request_namings",
+			JsonIt:    controllers.GetJSONItr(requestv1alpha1.GetEncoder(), requestv1alpha1.GetDecoder()),
+			Validator: &requestv1alpha1.Namings{},
+		}).SetupWebhookWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "Namings")
 			return err
 		}
@@ -1237,7 +1840,15 @@ func SetupWebhook(mgr manager.Manager, gvk schema.GroupVersionKind) error {
 		Version: "v1alpha1",
 		Kind:    "Attributes",
 	}:
-		if err := (&resourcev1alpha1.Attributes{}).SetupWebhookWithManager(mgr); err != nil {
+		if err := (&controllers.ResourceValidator{
+			Client:    mgr.GetClient(),
+			Gvk:       gvk,
+			Provider:  _provider,
+			Resource:  _provider.ResourcesMap["dynatrace_resource_attributes"],
+			TypeName:  "dynatrace_resource_attributes",
+			JsonIt:    controllers.GetJSONItr(resourcev1alpha1.GetEncoder(), resourcev1alpha1.GetDecoder()),
+			Validator: &resourcev1alpha1.Attributes{},
+		}).SetupWebhookWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "Attributes")
 			return err
 		}
@@ -1246,7 +1857,15 @@ func SetupWebhook(mgr manager.Manager, gvk schema.GroupVersionKind) error {
 		Version: "v1alpha1",
 		Kind:    "Anomalies",
 	}:
-		if err := (&servicev1alpha1.Anomalies{}).SetupWebhookWithManager(mgr); err != nil {
+		if err := (&controllers.ResourceValidator{
+			Client:    mgr.GetClient(),
+			Gvk:       gvk,
+			Provider:  _provider,
+			Resource:  _provider.ResourcesMap["dynatrace_service_anomalies"],
+			TypeName:  "dynatrace_service_anomalies",
+			JsonIt:    controllers.GetJSONItr(servicev1alpha1.GetEncoder(), servicev1alpha1.GetDecoder()),
+			Validator: &servicev1alpha1.Anomalies{},
+		}).SetupWebhookWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "Anomalies")
 			return err
 		}
@@ -1255,7 +1874,15 @@ func SetupWebhook(mgr manager.Manager, gvk schema.GroupVersionKind) error {
 		Version: "v1alpha1",
 		Kind:    "Naming",
 	}:
-		if err := (&servicev1alpha1.Naming{}).SetupWebhookWithManager(mgr); err != nil {
+		if err := (&controllers.ResourceValidator{
+			Client:    mgr.GetClient(),
+			Gvk:       gvk,
+			Provider:  _provider,
+			Resource:  _provider.ResourcesMap["dynatrace_service_naming"],
+			TypeName:  "dynatrace_service_naming",
+			JsonIt:    controllers.GetJSONItr(servicev1alpha1.GetEncoder(), servicev1alpha1.GetDecoder()),
+			Validator: &servicev1alpha1.Naming{},
+		}).SetupWebhookWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "Naming")
 			return err
 		}
@@ -1264,7 +1891,15 @@ func SetupWebhook(mgr manager.Manager, gvk schema.GroupVersionKind) error {
 		Version: "v1alpha1",
 		Kind:    "Slo",
 	}:
-		if err := (&slov1alpha1.Slo{}).SetupWebhookWithManager(mgr); err != nil {
+		if err := (&controllers.ResourceValidator{
+			Client:    mgr.GetClient(),
+			Gvk:       gvk,
+			Provider:  _provider,
+			Resource:  _provider.ResourcesMap["dynatrace_slo"],
+			TypeName:  "dynatrace_slo",
+			JsonIt:    controllers.GetJSONItr(slov1alpha1.GetEncoder(), slov1alpha1.GetDecoder()),
+			Validator: &slov1alpha1.Slo{},
+		}).SetupWebhookWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "Slo")
 			return err
 		}
@@ -1273,7 +1908,15 @@ func SetupWebhook(mgr manager.Manager, gvk schema.GroupVersionKind) error {
 		Version: "v1alpha1",
 		Kind:    "Attribute",
 	}:
-		if err := (&spanv1alpha1.Attribute{}).SetupWebhookWithManager(mgr); err != nil {
+		if err := (&controllers.ResourceValidator{
+			Client:    mgr.GetClient(),
+			Gvk:       gvk,
+			Provider:  _provider,
+			Resource:  _provider.ResourcesMap["dynatrace_span_attribute"],
+			TypeName:  "dynatrace_span_attribute",
+			JsonIt:    controllers.GetJSONItr(spanv1alpha1.GetEncoder(), spanv1alpha1.GetDecoder()),
+			Validator: &spanv1alpha1.Attribute{},
+		}).SetupWebhookWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "Attribute")
 			return err
 		}
@@ -1282,7 +1925,15 @@ func SetupWebhook(mgr manager.Manager, gvk schema.GroupVersionKind) error {
 		Version: "v1alpha1",
 		Kind:    "CaptureRule",
 	}:
-		if err := (&spanv1alpha1.CaptureRule{}).SetupWebhookWithManager(mgr); err != nil {
+		if err := (&controllers.ResourceValidator{
+			Client:    mgr.GetClient(),
+			Gvk:       gvk,
+			Provider:  _provider,
+			Resource:  _provider.ResourcesMap["dynatrace_span_capture_rule"],
+			TypeName:  "dynatrace_span_capture_rule",
+			JsonIt:    controllers.GetJSONItr(spanv1alpha1.GetEncoder(), spanv1alpha1.GetDecoder()),
+			Validator: &spanv1alpha1.CaptureRule{},
+		}).SetupWebhookWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "CaptureRule")
 			return err
 		}
@@ -1291,7 +1942,15 @@ func SetupWebhook(mgr manager.Manager, gvk schema.GroupVersionKind) error {
 		Version: "v1alpha1",
 		Kind:    "ContextPropagation",
 	}:
-		if err := (&spanv1alpha1.ContextPropagation{}).SetupWebhookWithManager(mgr); err != nil {
+		if err := (&controllers.ResourceValidator{
+			Client:    mgr.GetClient(),
+			Gvk:       gvk,
+			Provider:  _provider,
+			Resource:  _provider.ResourcesMap["dynatrace_span_context_propagation"],
+			TypeName:  "dynatrace_span_context_propagation",
+			JsonIt:    controllers.GetJSONItr(spanv1alpha1.GetEncoder(), spanv1alpha1.GetDecoder()),
+			Validator: &spanv1alpha1.ContextPropagation{},
+		}).SetupWebhookWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "ContextPropagation")
 			return err
 		}
@@ -1300,7 +1959,15 @@ func SetupWebhook(mgr manager.Manager, gvk schema.GroupVersionKind) error {
 		Version: "v1alpha1",
 		Kind:    "EntryPoint",
 	}:
-		if err := (&spanv1alpha1.EntryPoint{}).SetupWebhookWithManager(mgr); err != nil {
+		if err := (&controllers.ResourceValidator{
+			Client:    mgr.GetClient(),
+			Gvk:       gvk,
+			Provider:  _provider,
+			Resource:  _provider.ResourcesMap["dynatrace_span_entry_point"],
+			TypeName:  "dynatrace_span_entry_point",
+			JsonIt:    controllers.GetJSONItr(spanv1alpha1.GetEncoder(), spanv1alpha1.GetDecoder()),
+			Validator: &spanv1alpha1.EntryPoint{},
+		}).SetupWebhookWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "EntryPoint")
 			return err
 		}
@@ -1309,7 +1976,15 @@ func SetupWebhook(mgr manager.Manager, gvk schema.GroupVersionKind) error {
 		Version: "v1alpha1",
 		Kind:    "User",
 	}:
-		if err := (&userv1alpha1.User{}).SetupWebhookWithManager(mgr); err != nil {
+		if err := (&controllers.ResourceValidator{
+			Client:    mgr.GetClient(),
+			Gvk:       gvk,
+			Provider:  _provider,
+			Resource:  _provider.ResourcesMap["dynatrace_user"],
+			TypeName:  "dynatrace_user",
+			JsonIt:    controllers.GetJSONItr(userv1alpha1.GetEncoder(), userv1alpha1.GetDecoder()),
+			Validator: &userv1alpha1.User{},
+		}).SetupWebhookWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "User")
 			return err
 		}
@@ -1318,7 +1993,15 @@ func SetupWebhook(mgr manager.Manager, gvk schema.GroupVersionKind) error {
 		Version: "v1alpha1",
 		Kind:    "Group",
 	}:
-		if err := (&userv1alpha1.Group{}).SetupWebhookWithManager(mgr); err != nil {
+		if err := (&controllers.ResourceValidator{
+			Client:    mgr.GetClient(),
+			Gvk:       gvk,
+			Provider:  _provider,
+			Resource:  _provider.ResourcesMap["dynatrace_user_group"],
+			TypeName:  "dynatrace_user_group",
+			JsonIt:    controllers.GetJSONItr(userv1alpha1.GetEncoder(), userv1alpha1.GetDecoder()),
+			Validator: &userv1alpha1.Group{},
+		}).SetupWebhookWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "Group")
 			return err
 		}
@@ -1327,7 +2010,15 @@ func SetupWebhook(mgr manager.Manager, gvk schema.GroupVersionKind) error {
 		Version: "v1alpha1",
 		Kind:    "Application",
 	}:
-		if err := (&webv1alpha1.Application{}).SetupWebhookWithManager(mgr); err != nil {
+		if err := (&controllers.ResourceValidator{
+			Client:    mgr.GetClient(),
+			Gvk:       gvk,
+			Provider:  _provider,
+			Resource:  _provider.ResourcesMap["dynatrace_web_application"],
+			TypeName:  "dynatrace_web_application",
+			JsonIt:    controllers.GetJSONItr(webv1alpha1.GetEncoder(), webv1alpha1.GetDecoder()),
+			Validator: &webv1alpha1.Application{},
+		}).SetupWebhookWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "Application")
 			return err
 		}